@@ -1,11 +1,18 @@
 package ast
 
 import (
+	"strconv"
+	"strings"
+
 	"github.com/0xedb/intlang/token"
 )
 
+// Node is implemented by every AST node. String renders the node back as
+// source, fully parenthesising operators so that tests can check how an
+// expression was grouped.
 type Node interface {
 	TokenValue() string
+	String() string
 }
 
 type Statement interface {
@@ -27,17 +34,30 @@ func (i *Identifier) expressionNode() {}
 func (i *Identifier) TokenValue() string {
 	return i.Token.Literal
 }
+func (i *Identifier) String() string {
+	return i.Value
+}
 
+// AtStatement declares a binding. Bindings are immutable unless declared
+// with `@ mut`, in which case they may be reassigned.
 type AtStatement struct {
-	Token      token.TokenObj
-	Identifier *Identifier
-	Value      Expression
+	Token   token.TokenObj
+	Mutable bool
+	Target  Pattern
+	Value   Expression
 }
 
 func (a *AtStatement) statementNode() {}
 func (a *AtStatement) TokenValue() string {
 	return a.Token.Literal
 }
+func (a *AtStatement) String() string {
+	if a.Mutable {
+		return "@ mut " + str(a.Target) + " = " + str(a.Value) + ";"
+	}
+
+	return "@ " + str(a.Target) + " = " + str(a.Value) + ";"
+}
 
 type Program struct {
 	Statements []Statement
@@ -51,6 +71,16 @@ func (p *Program) TokenValue() string {
 	return ""
 }
 
+func (p *Program) String() string {
+	var out strings.Builder
+
+	for _, s := range p.Statements {
+		out.WriteString(s.String())
+	}
+
+	return out.String()
+}
+
 type ReturnStatement struct {
 	Token       token.TokenObj
 	ReturnValue Expression
@@ -62,6 +92,37 @@ func (r *ReturnStatement) TokenValue() string {
 	return r.Token.Literal
 }
 
+func (r *ReturnStatement) String() string {
+	if r.ReturnValue == nil {
+		return "ret;"
+	}
+
+	return "ret " + r.ReturnValue.String() + ";"
+}
+
+// ThrowStatement raises Value as an error, unwinding to the nearest
+// enclosing try that catches it.
+type ThrowStatement struct {
+	Token token.TokenObj // the 'throw' token
+	Value Expression
+}
+
+func (ts *ThrowStatement) statementNode()     {}
+func (ts *ThrowStatement) TokenValue() string { return ts.Token.Literal }
+func (ts *ThrowStatement) String() string     { return "throw " + str(ts.Value) + ";" }
+
+// DeferStatement schedules Call to run when the enclosing function returns.
+// The function and arguments of the call are evaluated when the defer
+// statement runs.
+type DeferStatement struct {
+	Token token.TokenObj // the 'defer' token
+	Call  *CallExpression
+}
+
+func (ds *DeferStatement) statementNode()     {}
+func (ds *DeferStatement) TokenValue() string { return ds.Token.Literal }
+func (ds *DeferStatement) String() string     { return "defer " + str(ds.Call) + ";" }
+
 type ExpressionStatement struct {
 	Token      token.TokenObj
 	Expression Expression
@@ -73,6 +134,10 @@ func (e *ExpressionStatement) TokenValue() string {
 	return e.Token.Literal
 }
 
+func (e *ExpressionStatement) String() string {
+	return str(e.Expression)
+}
+
 type IntegralExpression struct {
 	Token token.TokenObj
 	Value int64
@@ -82,6 +147,9 @@ func (i *IntegralExpression) expressionNode() {}
 func (i *IntegralExpression) TokenValue() string {
 	return i.Token.Literal
 }
+func (i *IntegralExpression) String() string {
+	return i.Token.Literal
+}
 
 type PrefixExpression struct {
 	Token    token.TokenObj
@@ -93,6 +161,9 @@ func (p *PrefixExpression) expressionNode() {}
 func (p *PrefixExpression) TokenValue() string {
 	return p.Token.Literal
 }
+func (p *PrefixExpression) String() string {
+	return "(" + p.Operator + str(p.Right) + ")"
+}
 
 type InfixExpression struct {
 	Token       token.TokenObj
@@ -104,6 +175,9 @@ func (i *InfixExpression) expressionNode() {}
 func (i *InfixExpression) TokenValue() string {
 	return i.Token.Literal
 }
+func (i *InfixExpression) String() string {
+	return "(" + str(i.Left) + " " + i.Operator + " " + str(i.Right) + ")"
+}
 
 type Boolean struct {
 	Token token.TokenObj
@@ -112,6 +186,7 @@ type Boolean struct {
 
 func (b *Boolean) expressionNode()    {}
 func (b *Boolean) TokenValue() string { return b.Token.Literal }
+func (b *Boolean) String() string     { return b.Token.Literal }
 
 type IfExpression struct {
 	Token       token.TokenObj // The 'if' token
@@ -122,6 +197,45 @@ type IfExpression struct {
 
 func (ie *IfExpression) expressionNode()    {}
 func (ie *IfExpression) TokenValue() string { return ie.Token.Literal }
+func (ie *IfExpression) String() string {
+	out := "if (" + str(ie.Condition) + ") " + ie.Consequence.String()
+
+	if ie.Alternative != nil {
+		out += " el " + ie.Alternative.String()
+	}
+
+	return out
+}
+
+// TryExpression runs Block, handing any error it raises to Catch, and then
+// runs Finally however the two ended. It has at least one of Catch and
+// Finally; Param, the name the caught error is bound to, is optional.
+type TryExpression struct {
+	Token   token.TokenObj // the 'try' token
+	Block   *BlockStatement
+	Param   *Identifier
+	Catch   *BlockStatement
+	Finally *BlockStatement
+}
+
+func (te *TryExpression) expressionNode()    {}
+func (te *TryExpression) TokenValue() string { return te.Token.Literal }
+func (te *TryExpression) String() string {
+	out := "try " + te.Block.String()
+
+	if te.Catch != nil {
+		out += " catch "
+		if te.Param != nil {
+			out += "(" + te.Param.String() + ") "
+		}
+		out += te.Catch.String()
+	}
+	if te.Finally != nil {
+		out += " finally " + te.Finally.String()
+	}
+
+	return out
+}
 
 type BlockStatement struct {
 	Token      token.TokenObj // the { token
@@ -130,21 +244,462 @@ type BlockStatement struct {
 
 func (bs *BlockStatement) statementNode()     {}
 func (bs *BlockStatement) TokenValue() string { return bs.Token.Literal }
+func (bs *BlockStatement) String() string {
+	if len(bs.Statements) == 0 {
+		return "{ }"
+	}
+
+	stmts := make([]string, len(bs.Statements))
+	for i, s := range bs.Statements {
+		stmts[i] = strings.TrimSuffix(s.String(), ";")
+	}
+
+	return "{ " + strings.Join(stmts, "; ") + " }"
+}
+
+// Parameter is one entry in a function's parameter list: a name or pattern,
+// optionally with a default value used when no argument is passed for it, or
+// a trailing rest parameter that collects the remaining positional arguments
+// into an array.
+type Parameter struct {
+	Target  Pattern    // an *Identifier if Rest
+	Default Expression // nil if the parameter is required
+	Rest    bool
+}
+
+func (p *Parameter) String() string {
+	switch {
+	case p.Rest:
+		return "..." + str(p.Target)
+	case p.Default != nil:
+		return str(p.Target) + " = " + p.Default.String()
+	}
+
+	return str(p.Target)
+}
 
 type FunctionLiteral struct {
-	Token      token.TokenObj // The 'fn' token
-	Parameters []*Identifier
+	Token      token.TokenObj // The 'fn' token, or '=>' for an arrow function
+	Name       string         // empty unless the function is declared by name
+	Parameters []*Parameter
 	Body       *BlockStatement
 }
 
 func (fl *FunctionLiteral) expressionNode()    {}
 func (fl *FunctionLiteral) TokenValue() string { return fl.Token.Literal }
+func (fl *FunctionLiteral) String() string {
+	params := make([]string, len(fl.Parameters))
+	for i, p := range fl.Parameters {
+		params[i] = p.String()
+	}
+
+	return "fn" + prefixSpace(fl.Name) + "(" + strings.Join(params, ", ") + ") " + fl.Body.String()
+}
+
+func prefixSpace(s string) string {
+	if s == "" {
+		return ""
+	}
 
+	return " " + s
+}
+
+// FunctionStatement declares a named function: `fn name(params) { ... }`.
+// Declarations are hoisted to the start of the enclosing block or program,
+// so they may be called before they appear and may call each other.
+type FunctionStatement struct {
+	Token    token.TokenObj // The 'fn' token
+	Name     *Identifier
+	Function *FunctionLiteral
+}
+
+func (fs *FunctionStatement) statementNode()     {}
+func (fs *FunctionStatement) TokenValue() string { return fs.Token.Literal }
+func (fs *FunctionStatement) String() string     { return fs.Function.String() }
+
+// NamedArgument is an argument passed by parameter name: `f(x: 1)`.
+type NamedArgument struct {
+	Name  *Identifier
+	Value Expression
+}
+
+// CallExpression calls Function. An optional call, `f?.(x)`, evaluates to
+// null without evaluating its arguments if Function is null.
 type CallExpression struct {
 	Token     token.TokenObj // The '(' token
 	Function  Expression     // Identifier or FunctionLiteral
 	Arguments []Expression
+	Named     []*NamedArgument // always after the positional Arguments
+	Optional  bool
+	Tail      bool // whether the function making the call returns its value
 }
 
 func (ce *CallExpression) expressionNode()    {}
 func (ce *CallExpression) TokenValue() string { return ce.Token.Literal }
+func (ce *CallExpression) String() string {
+	args := join(ce.Arguments)
+	for _, arg := range ce.Named {
+		if args != "" {
+			args += ", "
+		}
+		args += arg.Name.String() + ": " + str(arg.Value)
+	}
+
+	if ce.Optional {
+		return str(ce.Function) + "?.(" + args + ")"
+	}
+
+	return str(ce.Function) + "(" + args + ")"
+}
+
+// Placeholder stands for an argument left out of a call, `add(1, _)`, which
+// makes the call a partial application of the function.
+type Placeholder struct {
+	Token token.TokenObj // the '_' token
+}
+
+func (ph *Placeholder) expressionNode()    {}
+func (ph *Placeholder) TokenValue() string { return ph.Token.Literal }
+func (ph *Placeholder) String() string     { return "_" }
+
+// str renders a node that may be missing after a parse error.
+func str(n Node) string {
+	if n == nil {
+		return ""
+	}
+
+	return n.String()
+}
+
+func join(exps []Expression) string {
+	out := make([]string, len(exps))
+	for i, e := range exps {
+		out[i] = str(e)
+	}
+
+	return strings.Join(out, ", ")
+}
+
+type StringLiteral struct {
+	Token token.TokenObj
+	Value string
+}
+
+func (sl *StringLiteral) expressionNode()    {}
+func (sl *StringLiteral) TokenValue() string { return sl.Token.Literal }
+func (sl *StringLiteral) String() string     { return strconv.Quote(sl.Value) }
+
+type ArrayLiteral struct {
+	Token    token.TokenObj // the [ token
+	Elements []Expression
+}
+
+func (al *ArrayLiteral) expressionNode()    {}
+func (al *ArrayLiteral) TokenValue() string { return al.Token.Literal }
+func (al *ArrayLiteral) String() string     { return "[" + join(al.Elements) + "]" }
+
+// IndexExpression indexes Left. An optional index, `xs?[0]`, evaluates to
+// null without evaluating Index if Left is null.
+type IndexExpression struct {
+	Token    token.TokenObj // the [ or ?[ token
+	Left     Expression
+	Index    Expression
+	Optional bool
+}
+
+func (ie *IndexExpression) expressionNode()    {}
+func (ie *IndexExpression) TokenValue() string { return ie.Token.Literal }
+func (ie *IndexExpression) String() string {
+	if ie.Optional {
+		return "(" + str(ie.Left) + "?[" + str(ie.Index) + "])"
+	}
+
+	return "(" + str(ie.Left) + "[" + str(ie.Index) + "])"
+}
+
+// HashPair is an entry of a hash literal. A pair whose Key is a
+// *SpreadExpression has no Value: it copies every pair of the spread hash.
+type HashPair struct {
+	Key, Value Expression
+}
+
+type HashLiteral struct {
+	Token token.TokenObj // the { token
+	Pairs []HashPair     // in source order
+}
+
+func (hl *HashLiteral) expressionNode()    {}
+func (hl *HashLiteral) TokenValue() string { return hl.Token.Literal }
+func (hl *HashLiteral) String() string {
+	pairs := make([]string, len(hl.Pairs))
+	for i, pair := range hl.Pairs {
+		if _, ok := pair.Key.(*SpreadExpression); ok {
+			pairs[i] = pair.Key.String()
+			continue
+		}
+		pairs[i] = str(pair.Key) + ": " + str(pair.Value)
+	}
+
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+// EnumStatement declares a tagged union: `enum Shape { Circle(r), Rect(w, h) }`.
+// Each variant with fields becomes a constructor function, and each variant
+// without fields a value.
+type EnumStatement struct {
+	Token    token.TokenObj // the 'enum' token
+	Name     *Identifier
+	Variants []*EnumVariant
+}
+
+func (es *EnumStatement) statementNode()     {}
+func (es *EnumStatement) TokenValue() string { return es.Token.Literal }
+func (es *EnumStatement) String() string {
+	variants := make([]string, len(es.Variants))
+	for i, v := range es.Variants {
+		variants[i] = v.String()
+	}
+
+	return "enum " + str(es.Name) + " { " + strings.Join(variants, ", ") + " }"
+}
+
+type EnumVariant struct {
+	Name   *Identifier
+	Fields []*Identifier // nil for a variant without fields
+}
+
+func (ev *EnumVariant) String() string {
+	if ev.Fields == nil {
+		return ev.Name.String()
+	}
+
+	fields := make([]string, len(ev.Fields))
+	for i, f := range ev.Fields {
+		fields[i] = f.String()
+	}
+
+	return ev.Name.String() + "(" + strings.Join(fields, ", ") + ")"
+}
+
+// StructStatement declares a record type with a fixed set of fields:
+// `struct Point { x, y }`.
+type StructStatement struct {
+	Token  token.TokenObj // the 'struct' token
+	Name   *Identifier
+	Fields []*Identifier
+}
+
+func (ss *StructStatement) statementNode()     {}
+func (ss *StructStatement) TokenValue() string { return ss.Token.Literal }
+func (ss *StructStatement) String() string {
+	fields := make([]string, len(ss.Fields))
+	for i, f := range ss.Fields {
+		fields[i] = f.String()
+	}
+
+	return "struct " + str(ss.Name) + " { " + strings.Join(fields, ", ") + " }"
+}
+
+// PropagateExpression is the postfix ? operator: it evaluates to the value
+// inside an ok result, and returns an err result from the enclosing
+// function as it is.
+type PropagateExpression struct {
+	Token token.TokenObj // the '?' token
+	Value Expression
+}
+
+func (pe *PropagateExpression) expressionNode()    {}
+func (pe *PropagateExpression) TokenValue() string { return pe.Token.Literal }
+func (pe *PropagateExpression) String() string     { return "(" + str(pe.Value) + "?)" }
+
+// ImplStatement declares methods for a struct or enum. A method whose first
+// parameter is named self is called on a value, `p.distance(q)`, which is
+// passed as self; any other method is called on the type itself,
+// `Point.origin()`.
+type ImplStatement struct {
+	Token   token.TokenObj // the 'impl' token
+	Type    *Identifier
+	Methods []*FunctionLiteral // each with a Name
+}
+
+func (is *ImplStatement) statementNode()     {}
+func (is *ImplStatement) TokenValue() string { return is.Token.Literal }
+func (is *ImplStatement) String() string {
+	methods := make([]string, len(is.Methods))
+	for i, m := range is.Methods {
+		methods[i] = m.String()
+	}
+
+	return "impl " + str(is.Type) + " { " + strings.Join(methods, "; ") + " }"
+}
+
+// StructLiteral constructs a value of a struct, giving every field a value:
+// `Point { x: 1, y: 2 }`.
+type StructLiteral struct {
+	Token  token.TokenObj // the struct's name
+	Name   *Identifier
+	Fields []*FieldValue // in source order
+}
+
+type FieldValue struct {
+	Name  *Identifier
+	Value Expression
+}
+
+func (sl *StructLiteral) expressionNode()    {}
+func (sl *StructLiteral) TokenValue() string { return sl.Token.Literal }
+func (sl *StructLiteral) String() string {
+	fields := make([]string, len(sl.Fields))
+	for i, f := range sl.Fields {
+		fields[i] = f.Name.String() + ": " + str(f.Value)
+	}
+
+	return str(sl.Name) + " { " + strings.Join(fields, ", ") + " }"
+}
+
+// MemberExpression reads a field of a struct, the value at a string key of
+// a hash or a variant of an enum: `point.x`. An optional member, `p?.x`,
+// evaluates to null if Object is null.
+type MemberExpression struct {
+	Token    token.TokenObj // the '.' or '?.' token
+	Object   Expression
+	Member   *Identifier
+	Optional bool
+}
+
+func (me *MemberExpression) expressionNode()    {}
+func (me *MemberExpression) TokenValue() string { return me.Token.Literal }
+func (me *MemberExpression) String() string {
+	if me.Optional {
+		return "(" + str(me.Object) + "?." + str(me.Member) + ")"
+	}
+
+	return "(" + str(me.Object) + "." + str(me.Member) + ")"
+}
+
+// MatchExpression evaluates to the body of the first arm whose pattern
+// matches Value and whose guard, if any, holds.
+type MatchExpression struct {
+	Token token.TokenObj // the 'match' token
+	Value Expression
+	Arms  []*MatchArm
+}
+
+func (me *MatchExpression) expressionNode()    {}
+func (me *MatchExpression) TokenValue() string { return me.Token.Literal }
+func (me *MatchExpression) String() string {
+	arms := make([]string, len(me.Arms))
+	for i, arm := range me.Arms {
+		arms[i] = arm.String()
+	}
+
+	return "match (" + str(me.Value) + ") { " + strings.Join(arms, ", ") + " }"
+}
+
+// MatchArm is one `pattern if guard => body` arm of a match. The names bound
+// by the pattern are only visible in the guard and the body.
+type MatchArm struct {
+	Token   token.TokenObj // the first token of the pattern
+	Pattern Pattern
+	Guard   Expression // nil if the arm has no guard
+	Body    Node       // an Expression or a *BlockStatement
+}
+
+func (ma *MatchArm) String() string {
+	guard := ""
+	if ma.Guard != nil {
+		guard = " if " + ma.Guard.String()
+	}
+
+	return str(ma.Pattern) + guard + " => " + str(ma.Body)
+}
+
+// SpreadExpression expands the elements of Value in place: `f(...args)`,
+// `[0, ...xs]` and `{...defaults, "k": 1}`.
+type SpreadExpression struct {
+	Token token.TokenObj // the '...' token
+	Value Expression
+}
+
+func (se *SpreadExpression) expressionNode()    {}
+func (se *SpreadExpression) TokenValue() string { return se.Token.Literal }
+func (se *SpreadExpression) String() string     { return "..." + str(se.Value) }
+
+type WhileStatement struct {
+	Token     token.TokenObj // the 'while' token
+	Label     *Identifier    // nil unless the loop is labelled
+	Condition Expression
+	Body      *BlockStatement
+}
+
+func (ws *WhileStatement) statementNode()     {}
+func (ws *WhileStatement) TokenValue() string { return ws.Token.Literal }
+func (ws *WhileStatement) String() string {
+	return label(ws.Label) + "while (" + str(ws.Condition) + ") " + ws.Body.String()
+}
+
+// ForStatement is a for-in loop. With one variable, Value receives each
+// element of an array, character of a string, key of a hash or integer of a
+// range; with two, Key also receives the index (or hash key) and Value the
+// element (or hash value).
+type ForStatement struct {
+	Token    token.TokenObj // the 'for' token
+	Label    *Identifier    // nil unless the loop is labelled
+	Key      *Identifier    // nil when only one variable is given
+	Value    *Identifier
+	Iterable Expression
+	Body     *BlockStatement
+}
+
+func (fs *ForStatement) statementNode()     {}
+func (fs *ForStatement) TokenValue() string { return fs.Token.Literal }
+func (fs *ForStatement) String() string {
+	vars := fs.Value.String()
+	if fs.Key != nil {
+		vars = fs.Key.String() + ", " + vars
+	}
+
+	return label(fs.Label) + "for (" + vars + " in " + str(fs.Iterable) + ") " + fs.Body.String()
+}
+
+// BranchStatement is a break or continue, optionally naming the labelled
+// loop it applies to.
+type BranchStatement struct {
+	Token token.TokenObj // the 'break' or 'continue' token
+	Label *Identifier
+}
+
+func (bs *BranchStatement) statementNode()     {}
+func (bs *BranchStatement) TokenValue() string { return bs.Token.Literal }
+func (bs *BranchStatement) String() string {
+	if bs.Label == nil {
+		return bs.Token.Literal + ";"
+	}
+
+	return bs.Token.Literal + " " + bs.Label.String() + ";"
+}
+
+func label(l *Identifier) string {
+	if l == nil {
+		return ""
+	}
+
+	return l.String() + ": "
+}
+
+// AssignExpression stores Value into an existing variable, into an element
+// of an array or hash, or into a member. For compound operators such as +=,
+// the stored value is the result of applying the operator to the current
+// value and Value.
+type AssignExpression struct {
+	Token    token.TokenObj // the assignment operator
+	Target   Expression     // *Identifier, *IndexExpression or *MemberExpression
+	Operator string
+	Value    Expression
+}
+
+func (ae *AssignExpression) expressionNode()    {}
+func (ae *AssignExpression) TokenValue() string { return ae.Token.Literal }
+func (ae *AssignExpression) String() string {
+	return "(" + str(ae.Target) + " " + ae.Operator + " " + str(ae.Value) + ")"
+}
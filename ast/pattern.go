@@ -0,0 +1,185 @@
+package ast
+
+import (
+	"strings"
+
+	"github.com/0xedb/intlang/token"
+)
+
+// Pattern is what a value is bound to: a name, or an array or hash pattern
+// taking a collection apart and binding its pieces.
+type Pattern interface {
+	Node
+	patternNode()
+}
+
+func (i *Identifier) patternNode() {}
+
+// PatternElement is a pattern with the value to use instead when the
+// collection being destructured has nothing to bind it to.
+type PatternElement struct {
+	Pattern Pattern
+	Default Expression // nil if the element is required
+}
+
+func (pe *PatternElement) String() string {
+	if pe.Default == nil {
+		return str(pe.Pattern)
+	}
+
+	return str(pe.Pattern) + " = " + str(pe.Default)
+}
+
+// ArrayPattern binds the elements of an array by position: `[a, b, ...rest]`.
+type ArrayPattern struct {
+	Token    token.TokenObj // the '[' token
+	Elements []*PatternElement
+	Rest     *Identifier // nil unless the pattern ends in ...rest
+}
+
+func (ap *ArrayPattern) patternNode()       {}
+func (ap *ArrayPattern) TokenValue() string { return ap.Token.Literal }
+func (ap *ArrayPattern) String() string {
+	elements := make([]string, 0, len(ap.Elements)+1)
+	for _, e := range ap.Elements {
+		elements = append(elements, e.String())
+	}
+	if ap.Rest != nil {
+		elements = append(elements, "..."+ap.Rest.String())
+	}
+
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+// HashEntryPattern binds the value at a string key of a hash. In the
+// shorthand `{name}` the value is bound to the key's own name.
+type HashEntryPattern struct {
+	Key   *Identifier
+	Value *PatternElement
+}
+
+func (he *HashEntryPattern) String() string {
+	if id, ok := he.Value.Pattern.(*Identifier); ok && id.Value == he.Key.Value {
+		return he.Value.String()
+	}
+
+	return he.Key.String() + ": " + he.Value.String()
+}
+
+// HashPattern binds values of a hash by key: `{name, age: years}`. Keys the
+// pattern does not mention are ignored.
+type HashPattern struct {
+	Token   token.TokenObj // the '{' token
+	Entries []*HashEntryPattern
+}
+
+func (hp *HashPattern) patternNode()       {}
+func (hp *HashPattern) TokenValue() string { return hp.Token.Literal }
+func (hp *HashPattern) String() string {
+	entries := make([]string, len(hp.Entries))
+	for i, e := range hp.Entries {
+		entries[i] = e.String()
+	}
+
+	return "{" + strings.Join(entries, ", ") + "}"
+}
+
+// LiteralPattern matches values equal to an integer, string or boolean
+// literal, or a negated integer literal.
+type LiteralPattern struct {
+	Value Expression
+}
+
+func (lp *LiteralPattern) patternNode()       {}
+func (lp *LiteralPattern) TokenValue() string { return lp.Value.TokenValue() }
+func (lp *LiteralPattern) String() string {
+	if neg, ok := lp.Value.(*PrefixExpression); ok {
+		return neg.Operator + str(neg.Right)
+	}
+
+	return str(lp.Value)
+}
+
+// WildcardPattern, `_`, matches any value without binding it.
+type WildcardPattern struct {
+	Token token.TokenObj // the '_' token
+}
+
+func (wp *WildcardPattern) patternNode()       {}
+func (wp *WildcardPattern) TokenValue() string { return wp.Token.Literal }
+func (wp *WildcardPattern) String() string     { return "_" }
+
+// VariantPattern matches a value of an enum variant, `Circle(r)`, matching
+// its fields against Fields. In match arms, a bare name starting with an
+// upper-case letter, `None`, is a VariantPattern without fields rather than
+// a name to bind.
+type VariantPattern struct {
+	Token  token.TokenObj // the variant's name
+	Name   *Identifier
+	Fields []Pattern // nil for a variant without fields
+}
+
+func (vp *VariantPattern) patternNode()       {}
+func (vp *VariantPattern) TokenValue() string { return vp.Token.Literal }
+func (vp *VariantPattern) String() string {
+	if vp.Fields == nil {
+		return vp.Name.String()
+	}
+
+	fields := make([]string, len(vp.Fields))
+	for i, f := range vp.Fields {
+		fields[i] = str(f)
+	}
+
+	return vp.Name.String() + "(" + strings.Join(fields, ", ") + ")"
+}
+
+// OrPattern matches a value matched by any of its alternatives, trying them
+// in order. Every alternative binds the same names.
+type OrPattern struct {
+	Alternatives []Pattern
+}
+
+func (op *OrPattern) patternNode()       {}
+func (op *OrPattern) TokenValue() string { return op.Alternatives[0].TokenValue() }
+func (op *OrPattern) String() string {
+	alternatives := make([]string, len(op.Alternatives))
+	for i, alt := range op.Alternatives {
+		alternatives[i] = str(alt)
+	}
+
+	return strings.Join(alternatives, " | ")
+}
+
+// PatternNames returns the names bound by p, in source order.
+func PatternNames(p Pattern) []*Identifier {
+	switch p := p.(type) {
+	case *Identifier:
+		return []*Identifier{p}
+	case *ArrayPattern:
+		var names []*Identifier
+		for _, e := range p.Elements {
+			names = append(names, PatternNames(e.Pattern)...)
+		}
+		if p.Rest != nil {
+			names = append(names, p.Rest)
+		}
+		return names
+	case *HashPattern:
+		var names []*Identifier
+		for _, e := range p.Entries {
+			names = append(names, PatternNames(e.Value.Pattern)...)
+		}
+		return names
+	case *VariantPattern:
+		var names []*Identifier
+		for _, f := range p.Fields {
+			names = append(names, PatternNames(f)...)
+		}
+		return names
+	case *OrPattern:
+		return PatternNames(p.Alternatives[0])
+	}
+
+	return nil
+}
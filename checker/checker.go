@@ -0,0 +1,358 @@
+// Package checker finds mistakes in a parsed program before it is evaluated.
+package checker
+
+import (
+	"fmt"
+
+	"github.com/0xedb/intlang/ast"
+	"github.com/0xedb/intlang/token"
+)
+
+// decl is what the checker knows about a name at some point in a scope.
+type decl struct {
+	mutable     bool
+	conditional bool // declared in a block that may not have run
+	pos         token.Position
+	variant     *variant // non-nil if the name is an enum variant
+}
+
+type enum struct {
+	name     string
+	variants []*variant
+}
+
+type variant struct {
+	enum   *enum
+	name   string
+	fields int
+}
+
+type scope struct {
+	decls map[string]decl
+	outer *scope
+}
+
+func newScope(outer *scope) *scope {
+	return &scope{decls: map[string]decl{}, outer: outer}
+}
+
+func (s *scope) lookup(name string) (decl, bool) {
+	for sc := s; sc != nil; sc = sc.outer {
+		if d, ok := sc.decls[name]; ok {
+			return d, true
+		}
+	}
+
+	return decl{}, false
+}
+
+// Checker walks programs looking for errors that can be found statically. It
+// mirrors the evaluator's scoping: functions and loop bodies introduce
+// scopes, other blocks do not.
+// A Checker remembers the top-level declarations of every program it has
+// checked, so a REPL can check one line at a time.
+type Checker struct {
+	errors    []string
+	warnings  []string
+	global    *scope
+	scope     *scope
+	functions int // how many function bodies the walk is inside
+}
+
+func New() *Checker {
+	global := newScope(nil)
+
+	return &Checker{errors: []string{}, warnings: []string{}, global: global, scope: global}
+}
+
+// Check checks program and returns the errors found in it. Warnings about
+// code that is valid but most likely wrong are available from Warnings.
+func (c *Checker) Check(program *ast.Program) []string {
+	c.errors = []string{}
+	c.warnings = []string{}
+	c.scope = c.global
+
+	c.walkStatements(program.Statements)
+
+	return c.errors
+}
+
+func (c *Checker) Errors() []string {
+	return c.errors
+}
+
+// Warnings returns the warnings found by the last call to Check.
+func (c *Checker) Warnings() []string {
+	return c.warnings
+}
+
+func (c *Checker) error(pos token.Position, format string, args ...interface{}) {
+	msg := fmt.Sprintf("%s: %s", pos, fmt.Sprintf(format, args...))
+	c.errors = append(c.errors, msg)
+}
+
+func (c *Checker) warn(pos token.Position, format string, args ...interface{}) {
+	msg := fmt.Sprintf("%s: %s", pos, fmt.Sprintf(format, args...))
+	c.warnings = append(c.warnings, msg)
+}
+
+func (c *Checker) walk(node ast.Node) {
+	switch node := node.(type) {
+	case *ast.AtStatement:
+		c.walk(node.Value)
+		c.checkRedeclared(ast.PatternNames(node.Target)...)
+		c.walkPattern(node.Target, node.Mutable)
+	case *ast.ReturnStatement:
+		c.walk(node.ReturnValue)
+	case *ast.ThrowStatement:
+		c.walk(node.Value)
+	case *ast.DeferStatement:
+		if c.functions == 0 {
+			c.error(node.Token.Pos, "defer outside a function")
+		}
+		c.walk(node.Call)
+	case *ast.ExpressionStatement:
+		c.walk(node.Expression)
+	case *ast.BlockStatement:
+		c.walkStatements(node.Statements)
+	case *ast.FunctionStatement:
+		c.walkFunction(node.Function)
+	case *ast.EnumStatement:
+		c.declareEnum(node)
+	case *ast.StructStatement:
+		c.checkRedeclared(node.Name)
+		c.scope.decls[node.Name.Value] = decl{pos: node.Name.Token.Pos}
+	case *ast.ImplStatement:
+		for _, method := range node.Methods {
+			c.walkFunction(method)
+		}
+	case *ast.WhileStatement:
+		c.walk(node.Condition)
+		c.walkLoop(node.Body)
+	case *ast.ForStatement:
+		c.walk(node.Iterable)
+		c.walkLoop(node.Body, node.Key, node.Value)
+
+	case *ast.PrefixExpression:
+		c.walk(node.Right)
+	case *ast.InfixExpression:
+		c.walk(node.Left)
+		c.walk(node.Right)
+	case *ast.IfExpression:
+		c.walk(node.Condition)
+		c.walkBranch(node.Consequence)
+		if node.Alternative != nil {
+			c.walkBranch(node.Alternative)
+		}
+	case *ast.MatchExpression:
+		c.walkMatch(node)
+	case *ast.TryExpression:
+		c.walkTry(node)
+	case *ast.FunctionLiteral:
+		c.walkFunction(node)
+	case *ast.CallExpression:
+		c.walk(node.Function)
+		c.walkAll(node.Arguments)
+		for _, arg := range node.Named {
+			c.walk(arg.Value)
+		}
+	case *ast.ArrayLiteral:
+		c.walkAll(node.Elements)
+	case *ast.IndexExpression:
+		c.walk(node.Left)
+		c.walk(node.Index)
+	case *ast.HashLiteral:
+		for _, pair := range node.Pairs {
+			c.walk(pair.Key)
+			c.walk(pair.Value)
+		}
+	case *ast.SpreadExpression:
+		c.walk(node.Value)
+	case *ast.StructLiteral:
+		for _, field := range node.Fields {
+			c.walk(field.Value)
+		}
+	case *ast.MemberExpression:
+		c.walk(node.Object)
+	case *ast.PropagateExpression:
+		if c.functions == 0 {
+			c.error(node.Token.Pos, "? outside a function")
+		}
+		c.walk(node.Value)
+	case *ast.AssignExpression:
+		c.walk(node.Value)
+		c.walkAssignment(node)
+	}
+}
+
+// walkStatements walks a program or block after declaring the functions it
+// declares by name, which the evaluator hoists.
+func (c *Checker) walkStatements(stmts []ast.Statement) {
+	declared := map[string]token.Position{}
+
+	for _, stmt := range stmts {
+		fn, ok := stmt.(*ast.FunctionStatement)
+		if !ok {
+			continue
+		}
+
+		name := fn.Name.Value
+		if pos, ok := declared[name]; ok {
+			c.error(fn.Name.Token.Pos, "function %s redeclared in this block (previous declaration at %s)", name, pos)
+		} else {
+			c.checkRedeclared(fn.Name)
+		}
+		declared[name] = fn.Name.Token.Pos
+		c.scope.decls[name] = decl{pos: fn.Name.Token.Pos}
+	}
+
+	for _, stmt := range stmts {
+		c.walk(stmt)
+	}
+}
+
+func (c *Checker) walkAll(exps []ast.Expression) {
+	for _, e := range exps {
+		c.walk(e)
+	}
+}
+
+// walkAssignment rejects assignments to names whose every possible
+// declaration at this point is immutable. Names it cannot resolve are left
+// to the evaluator.
+func (c *Checker) walkAssignment(node *ast.AssignExpression) {
+	switch target := node.Target.(type) {
+	case *ast.Identifier:
+		if d, ok := c.scope.lookup(target.Value); ok && !d.mutable {
+			c.error(node.Token.Pos, "cannot assign to immutable %s (declared at %s; use `@ mut` to allow reassignment)",
+				target.Value, d.pos)
+		}
+	case *ast.IndexExpression:
+		c.walk(target.Left)
+		c.walk(target.Index)
+	case *ast.MemberExpression:
+		c.walk(target.Object)
+	}
+}
+
+func (c *Checker) walkFunction(fn *ast.FunctionLiteral) {
+	outer := c.scope
+	c.scope = newScope(outer)
+	c.functions++
+	defer func() {
+		c.scope = outer
+		c.functions--
+	}()
+
+	// A default is evaluated at call time, once the parameters before it
+	// have been bound.
+	for _, param := range fn.Parameters {
+		c.walk(param.Default)
+		c.walkPattern(param.Target, true)
+	}
+
+	c.walk(fn.Body)
+}
+
+// walkPattern declares the names bound by pattern, walking the defaults in
+// it in the order the evaluator evaluates them.
+func (c *Checker) walkPattern(pattern ast.Pattern, mutable bool) {
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		// Like the evaluator, keep the first of two declarations in a scope.
+		if prev, ok := c.scope.decls[pattern.Value]; ok && !prev.conditional {
+			return
+		}
+		c.scope.decls[pattern.Value] = decl{mutable: mutable, pos: pattern.Token.Pos}
+	case *ast.ArrayPattern:
+		for _, element := range pattern.Elements {
+			c.walkPatternElement(element, mutable)
+		}
+		if pattern.Rest != nil {
+			c.walkPattern(pattern.Rest, mutable)
+		}
+	case *ast.HashPattern:
+		for _, entry := range pattern.Entries {
+			c.walkPatternElement(entry.Value, mutable)
+		}
+	case *ast.VariantPattern:
+		c.checkVariantPattern(pattern)
+		for _, field := range pattern.Fields {
+			c.walkPattern(field, mutable)
+		}
+	case *ast.OrPattern:
+		for _, alt := range pattern.Alternatives {
+			c.walkPattern(alt, mutable)
+		}
+	}
+}
+
+func (c *Checker) walkPatternElement(element *ast.PatternElement, mutable bool) {
+	c.walk(element.Default)
+	c.walkPattern(element.Pattern, mutable)
+}
+
+// walkLoop walks the body of a loop, which like a function body has a scope
+// of its own, in which the loop variables vars are bound mutably.
+func (c *Checker) walkLoop(body *ast.BlockStatement, vars ...*ast.Identifier) {
+	outer := c.scope
+	c.scope = newScope(outer)
+	defer func() { c.scope = outer }()
+
+	for _, v := range vars {
+		if v != nil {
+			c.scope.decls[v.Value] = decl{mutable: true, pos: v.Token.Pos}
+		}
+	}
+	c.walk(body)
+}
+
+// walkBranch walks a block that may or may not run. Because blocks share
+// their enclosing scope, a declaration inside it may or may not be in effect
+// afterwards, so declaring the name again later is not certain to fail.
+func (c *Checker) walkBranch(block *ast.BlockStatement) {
+	before := map[string]decl{}
+	for name, d := range c.scope.decls {
+		before[name] = d
+	}
+
+	c.walk(block)
+
+	for name, d := range c.scope.decls {
+		if prev, ok := before[name]; !ok || d != prev {
+			d.conditional = true
+			c.scope.decls[name] = d
+		}
+	}
+}
+
+// checkRedeclared reports the names about to be declared in the current
+// scope that it already declares. As in the evaluator, a name can only be
+// declared once in a scope.
+func (c *Checker) checkRedeclared(names ...*ast.Identifier) {
+	for _, name := range names {
+		if prev, ok := c.scope.decls[name.Value]; ok && !prev.conditional {
+			c.error(name.Token.Pos, "%s redeclared in this block (previous declaration at %s)", name.Value, prev.pos)
+		}
+	}
+}
+
+// walkTry walks a try expression. Like a match arm, the catch block has a
+// scope of its own, in which the caught error is bound immutably.
+func (c *Checker) walkTry(node *ast.TryExpression) {
+	c.walkBranch(node.Block)
+
+	if node.Catch != nil {
+		outer := c.scope
+		c.scope = newScope(outer)
+		if node.Param != nil {
+			c.scope.decls[node.Param.Value] = decl{pos: node.Param.Token.Pos}
+		}
+		c.walk(node.Catch)
+		c.scope = outer
+	}
+
+	if node.Finally != nil {
+		c.walkBranch(node.Finally)
+	}
+}
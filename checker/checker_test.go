@@ -0,0 +1,191 @@
+package checker
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/0xedb/intlang/lexer"
+	"github.com/0xedb/intlang/parser"
+)
+
+func check(t *testing.T, c *Checker, input string) []string {
+	t.Helper()
+
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if errs := p.Errors(); len(errs) != 0 {
+		t.Fatalf("unexpected parser errors for %q: %v", input, errs)
+	}
+
+	return c.Check(program)
+}
+
+func TestImmutableAssignments(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		expect []string
+	}{
+		{"mutable", "@ mut x = 1\nx = 2\nx += 1", nil},
+		{"immutable", "@ x = 1\nx = 2", []string{"2:3: cannot assign to immutable x (declared at 1:3; use `@ mut` to allow reassignment)"}},
+		{"compound", "@ x = 1\nx *= 2", []string{"2:3: cannot assign to immutable x (declared at 1:3; use `@ mut` to allow reassignment)"}},
+		{"redeclared mutable", "@ x = 1\n@ mut x = 2\nx = 3", []string{"2:7: x redeclared in this block (previous declaration at 1:3)", "3:3: cannot assign to immutable x (declared at 1:3; use `@ mut` to allow reassignment)"}},
+		{"redeclared immutable", "@ mut x = 1\n@ x = 2\nx = 3", []string{"2:3: x redeclared in this block (previous declaration at 1:7)"}},
+		{"closure", "@ n = 0\n@ f = fn() { n += 1 }", []string{"2:16: cannot assign to immutable n (declared at 1:3; use `@ mut` to allow reassignment)"}},
+		{"shadowed by parameter", "@ n = 0\n@ f = fn(n) { n += 1 }", nil},
+		{"shadowed by local", "@ n = 0\n@ f = fn() { @ mut n = 1\nn = 2 }", nil},
+		{"local does not leak", "@ n = 0\n@ f = fn() { @ mut n = 1 }\nn = 2", []string{"3:3: cannot assign to immutable n (declared at 1:3; use `@ mut` to allow reassignment)"}},
+		{"loop variable", "for (i, x in [1]) { i = 0\nx = 0 }", nil},
+		{"in loop body", "@ x = 1\nwhile (true) { x = 2 }", []string{"2:18: cannot assign to immutable x (declared at 1:3; use `@ mut` to allow reassignment)"}},
+		{"loop variable shadows", "@ x = 1\nfor (x in [1]) { x = 2 }\nx = 3", []string{"3:3: cannot assign to immutable x (declared at 1:3; use `@ mut` to allow reassignment)"}},
+		{"loop scope", "@ x = 1\nwhile (c) { @ mut x = 2\nx = 3 }\nx = 4", []string{"4:3: cannot assign to immutable x (declared at 1:3; use `@ mut` to allow reassignment)"}},
+		{"redeclared in branch", "@ x = 1\nif (c) { @ mut x = 2 }", []string{"2:16: x redeclared in this block (previous declaration at 1:3)"}},
+		{"declared in branch", "if (c) { @ mut x = 1 }\nx = 2", nil},
+		{"declared in both branches", "if (c) { @ x = 1 } el { @ x = 2 }", nil},
+		{"redeclared in pattern", "@ a = 1\n@ [b, a] = [1, 2]", []string{"2:7: a redeclared in this block (previous declaration at 1:3)"}},
+		{"undeclared", "x = 1", nil},
+		{"index into immutable", "@ xs = [1]\nxs[0] = 2", nil},
+		{"default", "@ n = 0\nfn f(x = (n += 1)) { x }", []string{"2:13: cannot assign to immutable n (declared at 1:3; use `@ mut` to allow reassignment)"}},
+		{"named argument", "@ n = 0\nf(x: n = 1)", []string{"2:8: cannot assign to immutable n (declared at 1:3; use `@ mut` to allow reassignment)"}},
+		{"default sees earlier parameter", "fn f(x, y = (x = 1)) { y }", nil},
+		{"nested", "@ x = 1\n[fn() { if (true) { {1: x = 2} } }]", []string{"2:27: cannot assign to immutable x (declared at 1:3; use `@ mut` to allow reassignment)"}},
+		{"catch parameter", "try { f() } catch (e) { e = 1 }", []string{"1:27: cannot assign to immutable e (declared at 1:20; use `@ mut` to allow reassignment)"}},
+		{"catch scope", "@ e = 1\ntry { f() } catch (e) { }\ne = 2", []string{"3:3: cannot assign to immutable e (declared at 1:3; use `@ mut` to allow reassignment)"}},
+		{"throw", "@ n = 1\nthrow n = 2", []string{"2:9: cannot assign to immutable n (declared at 1:3; use `@ mut` to allow reassignment)"}},
+		{"method", "@ n = 0\nimpl P { fn f(self) { self = 1; n = 1 } }", []string{"2:35: cannot assign to immutable n (declared at 1:3; use `@ mut` to allow reassignment)"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			errs := check(t, New(), test.input)
+
+			if fmt.Sprint(errs) != fmt.Sprint(test.expect) && !(len(errs) == 0 && len(test.expect) == 0) {
+				t.Fatalf("Wanted: %v, Got: %v", test.expect, errs)
+			}
+		})
+	}
+}
+
+func TestCheckerRemembersGlobals(t *testing.T) {
+	c := New()
+
+	if errs := check(t, c, "@ x = 1"); len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if errs := check(t, c, "x = 2"); len(errs) != 1 {
+		t.Fatalf("Wanted one error, Got: %v", errs)
+	}
+}
+
+func TestFunctionDeclarations(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		expect []string
+	}{
+		{"hoisted", "f()\nfn f() { g() }\nfn g() { f() }", nil},
+		{"assign to function", "fn f() { }\nf = 1", []string{"2:3: cannot assign to immutable f (declared at 1:4; use `@ mut` to allow reassignment)"}},
+		{"assign before declaration", "f = 1\nfn f() { }", []string{"1:3: cannot assign to immutable f (declared at 2:4; use `@ mut` to allow reassignment)"}},
+		{"redeclared", "fn f() { }\nfn f() { }", []string{"2:4: function f redeclared in this block (previous declaration at 1:4)"}},
+		{"nested blocks", "fn f() { }\nif (true) { fn f() { } }", []string{"2:16: f redeclared in this block (previous declaration at 1:4)"}},
+		{"nested function", "fn f() { }\n@ g = fn() { fn f() { } }", nil},
+		{"redeclared by @", "fn f() { }\n@ f = 1", []string{"2:3: f redeclared in this block (previous declaration at 1:4)"}},
+		{"redeclared struct", "struct P { x }\nenum E { P }", []string{"2:10: P redeclared in this block (previous declaration at 1:8)"}},
+		{"defer outside function", "defer f()", []string{"1:1: defer outside a function"}},
+		{"defer in method", "impl P { fn f(self) { defer g() } }", nil},
+		{"? outside function", "@ x = err(1)?", []string{"1:13: ? outside a function"}},
+		{"? in lambda", "@ f = (r) => r? + 1", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			errs := check(t, New(), test.input)
+
+			if fmt.Sprint(errs) != fmt.Sprint(test.expect) && !(len(errs) == 0 && len(test.expect) == 0) {
+				t.Fatalf("Wanted: %v, Got: %v", test.expect, errs)
+			}
+		})
+	}
+}
+
+func TestPatterns(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		expect []string
+	}{
+		{"immutable", "@ [a, {b}] = xs\nb = 1", []string{"2:3: cannot assign to immutable b (declared at 1:8; use `@ mut` to allow reassignment)"}},
+		{"mutable", "@ mut [a, ...rest] = xs\nrest = []", nil},
+		{"default", "@ n = 0\n@ [a = (n = 1)] = xs", []string{"2:11: cannot assign to immutable n (declared at 1:3; use `@ mut` to allow reassignment)"}},
+		{"parameter", "@ n = 0\nfn f({n}) { n = 1 }", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			errs := check(t, New(), test.input)
+
+			if fmt.Sprint(errs) != fmt.Sprint(test.expect) && !(len(errs) == 0 && len(test.expect) == 0) {
+				t.Fatalf("Wanted: %v, Got: %v", test.expect, errs)
+			}
+		})
+	}
+}
+
+func TestMatchWarnings(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		expect []string
+	}{
+		{"fine", "match (x) { 0 => a, n if n > 0 => b, _ => c }", nil},
+		{"after catch-all", "match (x) { n => 1, 0 => 2 }", []string{"1:21: unreachable match arm: the arm at 1:13 matches every value"}},
+		{"duplicate literal", "match (x) { 16 => 1, 0x10 => 2 }", []string{"1:22: unreachable match arm: 0x10 is already matched by the arm at 1:13"}},
+		{"covered alternatives", "match (x) { 1 | 2 => 1, 2 => 2, 2 | 3 => 3 }", []string{"1:25: unreachable match arm: 2 is already matched by the arm at 1:13"}},
+		{"guarded arms do not cover", "match (x) { 1 if c => 1, 1 => 2 }", nil},
+		{"no arms", "match (x) { }", []string{"1:1: match has no arms, so no value can match"}},
+		{"constant", "match (3) { 1 => 0, \"3\" => 1, [a] => 2 }", []string{"1:1: no arm can match 3"}},
+		{"arm scope", "match (x) { [a] => 1 }\na = 2", nil},
+		{"immutable binding", "match (x) { a => a = 1 }", []string{"1:20: cannot assign to immutable a (declared at 1:13; use `@ mut` to allow reassignment)"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := New()
+			errs := append(check(t, c, test.input), c.Warnings()...)
+
+			if fmt.Sprint(errs) != fmt.Sprint(test.expect) && !(len(errs) == 0 && len(test.expect) == 0) {
+				t.Fatalf("Wanted: %v, Got: %v", test.expect, errs)
+			}
+		})
+	}
+}
+
+func TestEnums(t *testing.T) {
+	const shape = "enum Shape { Circle(r), Rect(w, h), Empty }\n"
+	tests := []struct {
+		name   string
+		input  string
+		expect []string
+	}{
+		{"exhaustive", shape + "match (s) { Circle(r) => r, Rect(w, h) => w, Empty => 0 }", nil},
+		{"catch-all", shape + "match (s) { Circle(r) => r, _ => 0 }", nil},
+		{"or-pattern", shape + "match (s) { Circle(_) | Rect(_, _) => 1, Empty => 0 }", nil},
+		{"missing", shape + "match (s) { Circle(r) => r }", []string{"2:1: match on Shape is not exhaustive: missing Rect, Empty"}},
+		{"guarded", shape + "match (s) { Circle(r) if r > 0 => r, Rect(w, h) => w, Empty => 0 }", []string{"2:1: match on Shape is not exhaustive: missing Circle"}},
+		{"refutable field", shape + "match (s) { Circle(0) => 0, Rect(w, h) => w, Empty => 0 }", []string{"2:1: match on Shape is not exhaustive: missing Circle"}},
+		{"field count", shape + "@ Rect(w) = s", []string{"2:3: pattern Rect(w) has 1 fields, but variant Rect has 2"}},
+		{"not a variant", "@ f = 1\n@ f(x) = y", []string{"2:3: f is not an enum variant"}},
+		{"immutable constructor", shape + "Circle = 1", []string{"2:8: cannot assign to immutable Circle (declared at 1:14; use `@ mut` to allow reassignment)"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := New()
+			errs := append(check(t, c, test.input), c.Warnings()...)
+
+			if fmt.Sprint(errs) != fmt.Sprint(test.expect) && !(len(errs) == 0 && len(test.expect) == 0) {
+				t.Fatalf("Wanted: %v, Got: %v", test.expect, errs)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package checker
+
+import (
+	"strings"
+
+	"github.com/0xedb/intlang/ast"
+)
+
+func (c *Checker) declareEnum(node *ast.EnumStatement) {
+	names := []*ast.Identifier{node.Name}
+	for _, v := range node.Variants {
+		names = append(names, v.Name)
+	}
+	c.checkRedeclared(names...)
+
+	e := &enum{name: node.Name.Value}
+	c.scope.decls[e.name] = decl{pos: node.Name.Token.Pos}
+
+	for _, v := range node.Variants {
+		info := &variant{enum: e, name: v.Name.Value, fields: len(v.Fields)}
+		e.variants = append(e.variants, info)
+		c.scope.decls[info.name] = decl{pos: v.Name.Token.Pos, variant: info}
+	}
+}
+
+// checkVariantPattern checks that a variant pattern names a known variant
+// and matches all of its fields. Names it cannot resolve are left to the
+// evaluator.
+func (c *Checker) checkVariantPattern(pattern *ast.VariantPattern) {
+	d, ok := c.scope.lookup(pattern.Name.Value)
+	switch {
+	case !ok:
+	case d.variant == nil:
+		c.error(pattern.Token.Pos, "%s is not an enum variant", pattern.Name.Value)
+	case len(pattern.Fields) != d.variant.fields:
+		c.error(pattern.Token.Pos, "pattern %s has %d fields, but variant %s has %d",
+			pattern, len(pattern.Fields), d.variant.name, d.variant.fields)
+	}
+}
+
+// checkExhaustive warns about a match on the variants of an enum that has
+// no arm for some of them and no arm matching every value.
+func (c *Checker) checkExhaustive(node *ast.MatchExpression) {
+	var e *enum
+	covered := map[string]bool{}
+
+	for _, arm := range node.Arms {
+		alternatives := []ast.Pattern{arm.Pattern}
+		if or, ok := arm.Pattern.(*ast.OrPattern); ok {
+			alternatives = or.Alternatives
+		}
+
+		for _, alt := range alternatives {
+			if arm.Guard == nil && irrefutable(alt) {
+				return
+			}
+
+			pattern, ok := alt.(*ast.VariantPattern)
+			if !ok {
+				continue
+			}
+			d, ok := c.scope.lookup(pattern.Name.Value)
+			if !ok || d.variant == nil {
+				continue
+			}
+
+			e = d.variant.enum
+			if arm.Guard == nil && allIrrefutable(pattern.Fields) {
+				covered[d.variant.name] = true
+			}
+		}
+	}
+
+	if e == nil {
+		return
+	}
+
+	var missing []string
+	for _, v := range e.variants {
+		if !covered[v.name] {
+			missing = append(missing, v.name)
+		}
+	}
+	if len(missing) > 0 {
+		c.warn(node.Token.Pos, "match on %s is not exhaustive: missing %s", e.name, strings.Join(missing, ", "))
+	}
+}
+
+func allIrrefutable(patterns []ast.Pattern) bool {
+	for _, p := range patterns {
+		if !irrefutable(p) {
+			return false
+		}
+	}
+
+	return true
+}
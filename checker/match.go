@@ -0,0 +1,152 @@
+package checker
+
+import (
+	"fmt"
+
+	"github.com/0xedb/intlang/ast"
+	"github.com/0xedb/intlang/token"
+)
+
+// walkMatch walks each arm of a match in a scope of its own, like the
+// evaluator, then warns about arms that can never be chosen.
+func (c *Checker) walkMatch(node *ast.MatchExpression) {
+	c.walk(node.Value)
+
+	for _, arm := range node.Arms {
+		outer := c.scope
+		c.scope = newScope(outer)
+
+		c.walkPattern(arm.Pattern, false)
+		c.walk(arm.Guard)
+		c.walk(arm.Body)
+
+		c.scope = outer
+	}
+
+	c.checkArms(node)
+}
+
+func (c *Checker) checkArms(node *ast.MatchExpression) {
+	if len(node.Arms) == 0 {
+		c.warn(node.Token.Pos, "match has no arms, so no value can match")
+		return
+	}
+
+	if value, ok := constant(node.Value); ok {
+		matched := false
+		for _, arm := range node.Arms {
+			matched = matched || canMatch(arm.Pattern, value)
+		}
+		if !matched {
+			c.warn(node.Token.Pos, "no arm can match %s", node.Value)
+		}
+	}
+
+	c.checkExhaustive(node)
+
+	var catchAll *ast.MatchArm
+	seen := map[string]token.Position{}
+
+	for _, arm := range node.Arms {
+		if catchAll != nil {
+			c.warn(arm.Token.Pos, "unreachable match arm: the arm at %s matches every value", catchAll.Token.Pos)
+			continue
+		}
+
+		alternatives := []ast.Pattern{arm.Pattern}
+		if or, ok := arm.Pattern.(*ast.OrPattern); ok {
+			alternatives = or.Alternatives
+		}
+
+		covered := true
+		var by token.Position
+		for _, alt := range alternatives {
+			pos, ok := seen[key(alt)]
+			covered = covered && ok
+			by = pos
+		}
+		if covered {
+			c.warn(arm.Token.Pos, "unreachable match arm: %s is already matched by the arm at %s", arm.Pattern, by)
+			continue
+		}
+
+		if arm.Guard != nil {
+			continue
+		}
+		for _, alt := range alternatives {
+			if _, ok := seen[key(alt)]; !ok {
+				seen[key(alt)] = arm.Token.Pos
+			}
+		}
+		if irrefutable(arm.Pattern) {
+			catchAll = arm
+		}
+	}
+}
+
+// key identifies what a pattern matches, so that equal literals spelled
+// differently, such as 16 and 0x10, are seen as the same pattern.
+func key(pattern ast.Pattern) string {
+	if lit, ok := pattern.(*ast.LiteralPattern); ok {
+		if value, ok := constant(lit.Value); ok {
+			return fmt.Sprintf("%T %v", value, value)
+		}
+	}
+
+	return pattern.String()
+}
+
+// constant returns the value of a literal expression: an int64, string or
+// bool.
+func constant(exp ast.Expression) (interface{}, bool) {
+	switch exp := exp.(type) {
+	case *ast.IntegralExpression:
+		return exp.Value, true
+	case *ast.StringLiteral:
+		return exp.Value, true
+	case *ast.Boolean:
+		return exp.Value, true
+	case *ast.PrefixExpression:
+		if lit, ok := exp.Right.(*ast.IntegralExpression); ok && exp.Operator == "-" {
+			return -lit.Value, true
+		}
+	}
+
+	return nil, false
+}
+
+// canMatch reports whether pattern may match the constant value.
+func canMatch(pattern ast.Pattern, value interface{}) bool {
+	switch pattern := pattern.(type) {
+	case *ast.LiteralPattern:
+		v, ok := constant(pattern.Value)
+		return !ok || v == value
+	case *ast.OrPattern:
+		for _, alt := range pattern.Alternatives {
+			if canMatch(alt, value) {
+				return true
+			}
+		}
+		return false
+	case *ast.ArrayPattern, *ast.HashPattern, *ast.VariantPattern:
+		return false
+	}
+
+	return true
+}
+
+// irrefutable reports whether pattern matches every value.
+func irrefutable(pattern ast.Pattern) bool {
+	switch pattern := pattern.(type) {
+	case *ast.Identifier, *ast.WildcardPattern:
+		return true
+	case *ast.OrPattern:
+		for _, alt := range pattern.Alternatives {
+			if irrefutable(alt) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
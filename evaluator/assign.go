@@ -0,0 +1,124 @@
+package evaluator
+
+import (
+	"strings"
+
+	"github.com/0xedb/intlang/ast"
+	"github.com/0xedb/intlang/object"
+)
+
+func evalAssignExpression(node *ast.AssignExpression, env *object.Environment) object.Object {
+	switch target := node.Target.(type) {
+	case *ast.Identifier:
+		return evalAssignIdentifier(node, target, env)
+	case *ast.IndexExpression:
+		return evalAssignIndex(node, target, env)
+	case *ast.MemberExpression:
+		return evalAssignMember(node, target, env)
+	}
+
+	return newError(object.TypeError, "cannot assign to %s", node.Target)
+}
+
+// evalAssignIdentifier updates a binding in the scope that declared it. The
+// checker rejects most assignments to immutable bindings before the program
+// runs, but not those it cannot resolve statically, such as a function body
+// assigning to a global declared after it; those are caught here.
+func evalAssignIdentifier(node *ast.AssignExpression, target *ast.Identifier, env *object.Environment) object.Object {
+	binding, ok := env.Lookup(target.Value)
+	if !ok {
+		return newError(object.NameError, "cannot assign to undeclared identifier: %s", target.Value)
+	}
+	if !binding.Mutable {
+		return newError(object.AssignmentError, "cannot assign to immutable %s (declared at %s; use `@ mut` to allow reassignment)",
+			target.Value, binding.Pos)
+	}
+
+	val := evalAssignedValue(node, binding.Value, env)
+	if isError(val) {
+		return val
+	}
+
+	binding.Value = val
+	return val
+}
+
+func evalAssignIndex(node *ast.AssignExpression, target *ast.IndexExpression, env *object.Environment) object.Object {
+	left := Eval(target.Left, env)
+	if isError(left) {
+		return left
+	}
+	index := Eval(target.Index, env)
+	if isError(index) {
+		return index
+	}
+
+	callsOf(env).site = node.Token.Pos
+	if method := methodOf(left, "__setindex__"); method != nil {
+		var current object.Object = NULL
+		if node.Operator != "=" {
+			current = evalIndexExpression(left, index, env)
+			if isError(current) {
+				return current
+			}
+		}
+
+		val := evalAssignedValue(node, current, env)
+		if isError(val) {
+			return val
+		}
+		if err := applyFunction(method, []object.Object{left, index, val}, nil, env); isError(err) {
+			return err
+		}
+		return val
+	}
+
+	switch container := left.(type) {
+	case *object.Array:
+		i, ok := index.(*object.Integer)
+		if !ok {
+			return newError(object.TypeError, "array index must be INTEGER, got %s", object.TypeName(index))
+		}
+		if i.Value < 0 || i.Value >= int64(len(container.Elements)) {
+			return newError(object.IndexError, "index out of range: %d with length %d", i.Value, len(container.Elements))
+		}
+
+		val := evalAssignedValue(node, container.Elements[i.Value], env)
+		if isError(val) {
+			return val
+		}
+		container.Elements[i.Value] = val
+		return val
+	case *object.Hash:
+		key, err := hashKey(container, index, env)
+		if err != nil {
+			return err
+		}
+
+		var current object.Object = NULL
+		if pair, ok := container.Pairs[key]; ok {
+			current = pair.Value
+		}
+
+		val := evalAssignedValue(node, current, env)
+		if isError(val) {
+			return val
+		}
+		container.Put(key, index, val)
+		return val
+	}
+
+	return newError(object.TypeError, "index assignment not supported: %s", object.TypeName(left))
+}
+
+// evalAssignedValue evaluates the right-hand side of an assignment and, for
+// a compound operator such as +=, combines it with the current value.
+func evalAssignedValue(node *ast.AssignExpression, current object.Object, env *object.Environment) object.Object {
+	val := Eval(node.Value, env)
+	if isError(val) || node.Operator == "=" {
+		return val
+	}
+
+	callsOf(env).site = node.Token.Pos
+	return evalInfixExpression(strings.TrimSuffix(node.Operator, "="), current, val, env)
+}
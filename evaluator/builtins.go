@@ -0,0 +1,304 @@
+package evaluator
+
+import (
+	"fmt"
+
+	"github.com/0xedb/intlang/object"
+)
+
+var builtins = map[string]*object.Builtin{
+	"len": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.String:
+				return &object.Integer{Value: int64(runeLen(arg.Value))}
+			case *object.Array:
+				return &object.Integer{Value: int64(len(arg.Elements))}
+			case *object.Hash:
+				return &object.Integer{Value: int64(len(arg.Keys))}
+			case *object.Range:
+				return &object.Integer{Value: arg.Len()}
+			}
+
+			return newError(object.TypeError, "argument to `len` not supported, got %s", object.TypeName(args[0]))
+		},
+	},
+	"first": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+			if args[0].Type() != object.ARRAY_OBJ {
+				return newError(object.TypeError, "argument to `first` must be ARRAY, got %s", object.TypeName(args[0]))
+			}
+
+			arr := args[0].(*object.Array)
+			if len(arr.Elements) > 0 {
+				return arr.Elements[0]
+			}
+
+			return NULL
+		},
+	},
+	"last": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+			if args[0].Type() != object.ARRAY_OBJ {
+				return newError(object.TypeError, "argument to `last` must be ARRAY, got %s", object.TypeName(args[0]))
+			}
+
+			arr := args[0].(*object.Array)
+			if length := len(arr.Elements); length > 0 {
+				return arr.Elements[length-1]
+			}
+
+			return NULL
+		},
+	},
+	"rest": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+			if args[0].Type() != object.ARRAY_OBJ {
+				return newError(object.TypeError, "argument to `rest` must be ARRAY, got %s", object.TypeName(args[0]))
+			}
+
+			arr := args[0].(*object.Array)
+			if length := len(arr.Elements); length > 0 {
+				elements := make([]object.Object, length-1)
+				copy(elements, arr.Elements[1:])
+				return &object.Array{Elements: elements}
+			}
+
+			return NULL
+		},
+	},
+	"push": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=2", len(args))
+			}
+			if args[0].Type() != object.ARRAY_OBJ {
+				return newError(object.TypeError, "argument to `push` must be ARRAY, got %s", object.TypeName(args[0]))
+			}
+
+			arr := args[0].(*object.Array)
+			elements := make([]object.Object, len(arr.Elements), len(arr.Elements)+1)
+			copy(elements, arr.Elements)
+
+			return &object.Array{Elements: append(elements, args[1])}
+		},
+	},
+	"ok": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			return &object.Result{Ok: true, Value: args[0]}
+		},
+	},
+	"err": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			return &object.Result{Ok: false, Value: args[0]}
+		},
+	},
+	"is_ok": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+			r, err := result("is_ok", args[0])
+			if err != nil {
+				return err
+			}
+
+			return nativeBoolToBooleanObject(r.Ok)
+		},
+	},
+	"is_err": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+			r, err := result("is_err", args[0])
+			if err != nil {
+				return err
+			}
+
+			return nativeBoolToBooleanObject(!r.Ok)
+		},
+	},
+	"unwrap": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+			r, err := result("unwrap", args[0])
+			if err != nil {
+				return err
+			}
+			if !r.Ok {
+				return newError(object.ResultError, "unwrap of %s", r.Inspect())
+			}
+
+			return r.Value
+		},
+	},
+	"unwrap_or": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=2", len(args))
+			}
+			r, err := result("unwrap_or", args[0])
+			if err != nil {
+				return err
+			}
+			if !r.Ok {
+				return args[1]
+			}
+
+			return r.Value
+		},
+	},
+	"puts": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			for _, arg := range args {
+				fmt.Println(arg.Inspect())
+			}
+
+			return NULL
+		},
+	},
+}
+
+func init() {
+	// map_ok and map_err call the function they are given, which may refer
+	// back to the builtins.
+	builtins["map_ok"] = &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			return mapResult("map_ok", args, true, env)
+		},
+	}
+	builtins["map_err"] = &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			return mapResult("map_err", args, false, env)
+		},
+	}
+
+	// recover, called by a deferred function, stops the error the function
+	// that deferred it is exiting with and returns it as a catch clause would
+	// see it. Anywhere else, including `defer recover()`, it returns null. It
+	// reads the call stack, which refers back to the evaluator.
+	builtins["recover"] = &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=0", len(args))
+			}
+
+			return recoverError(env)
+		},
+	}
+
+	// sort compares elements with <, which may call a user-defined __lt__
+	// and so the evaluator, which looks up builtins.
+	builtins["sort"] = &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+			arr, ok := args[0].(*object.Array)
+			if !ok {
+				return newError(object.TypeError, "argument to `sort` must be ARRAY, got %s", object.TypeName(args[0]))
+			}
+
+			return sortArray(arr, env)
+		},
+	}
+
+	// map, filter and sum call a function or + on each element, and so the
+	// evaluator, which looks up builtins.
+	builtins["map"] = &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			return eachElement("map", args, false, env)
+		},
+	}
+	builtins["filter"] = &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			return eachElement("filter", args, true, env)
+		},
+	}
+	builtins["sum"] = &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+			arr, ok := args[0].(*object.Array)
+			if !ok {
+				return newError(object.TypeError, "argument to `sum` must be ARRAY, got %s", object.TypeName(args[0]))
+			}
+
+			return sumArray(arr, env)
+		},
+	}
+}
+
+// eachElement calls the function in args[1] on each element of the array in
+// args[0]. For map it returns what the calls return; for filter, the
+// elements for which they return something truthy. It stops at the first
+// call that fails.
+func eachElement(name string, args []object.Object, filter bool, env *object.Environment) object.Object {
+	if len(args) != 2 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=2", len(args))
+	}
+	arr, ok := args[0].(*object.Array)
+	if !ok {
+		return newError(object.TypeError, "argument to `%s` must be ARRAY, got %s", name, object.TypeName(args[0]))
+	}
+
+	elements := []object.Object{}
+	for _, el := range arr.Elements {
+		val := applyFunction(args[1], []object.Object{el}, nil, env)
+		if isError(val) {
+			return val
+		}
+		switch {
+		case !filter:
+			elements = append(elements, val)
+		case isTruthy(val):
+			elements = append(elements, el)
+		}
+	}
+
+	return &object.Array{Elements: elements}
+}
+
+// sumArray adds up the elements of arr with +, so that it sums integers,
+// joins strings and calls a user-defined __add__. The sum of no elements
+// is 0.
+func sumArray(arr *object.Array, env *object.Environment) object.Object {
+	if len(arr.Elements) == 0 {
+		return &object.Integer{Value: 0}
+	}
+
+	total := arr.Elements[0]
+	for _, el := range arr.Elements[1:] {
+		total = evalInfixExpression("+", total, el, env)
+		if isError(total) {
+			return total
+		}
+	}
+
+	return total
+}
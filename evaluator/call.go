@@ -0,0 +1,187 @@
+package evaluator
+
+import (
+	"strings"
+
+	"github.com/0xedb/intlang/ast"
+	"github.com/0xedb/intlang/object"
+)
+
+func evalCallExpression(node *ast.CallExpression, env *object.Environment) object.Object {
+	function, args, named, err := evalCall(node, env)
+	if err != nil {
+		return err
+	}
+
+	if fn := partial(function, args, named); fn != nil {
+		return fn
+	}
+
+	if node.Tail {
+		return &object.TailCall{Function: function, Args: args, Named: named, Site: node.Token.Pos}
+	}
+
+	callsOf(env).site = node.Token.Pos
+	return applyFunction(function, args, named, env)
+}
+
+// evalCall evaluates the function and arguments of a call, without making
+// it. If the call cannot be made, its last result is what the call evaluates
+// to instead: an error, or null for an optional call of null.
+func evalCall(node *ast.CallExpression, env *object.Environment) (object.Object, []object.Object, []object.NamedArgument, object.Object) {
+	function, receiver, skip := evalCallee(node.Function, node.Optional, env)
+	if isError(function) {
+		return nil, nil, nil, function
+	}
+	if skip || node.Optional && function == NULL {
+		return nil, nil, nil, NULL
+	}
+
+	args := evalExpressions(node.Arguments, env)
+	if len(args) == 1 && isError(args[0]) {
+		return nil, nil, nil, args[0]
+	}
+	if receiver != nil {
+		args = append([]object.Object{receiver}, args...)
+	}
+
+	var named []object.NamedArgument
+	for _, arg := range node.Named {
+		value := Eval(arg.Value, env)
+		if isError(value) {
+			return nil, nil, nil, value
+		}
+		named = append(named, object.NamedArgument{Name: arg.Name.Value, Value: value})
+	}
+
+	return function, args, named, nil
+}
+
+func applyFunction(fn object.Object, args []object.Object, named []object.NamedArgument, env *object.Environment) object.Object {
+	switch fn := fn.(type) {
+	case *object.Function:
+		env, err := bindArguments(fn, args, named)
+		if err != nil {
+			return err
+		}
+		return callFunction(fn, env)
+	case *object.BoundMethod:
+		return applyFunction(fn.Method, append([]object.Object{fn.Receiver}, args...), named, env)
+	case *object.Partial:
+		return applyPartial(fn, args, named, env)
+	case *object.Variant:
+		return construct(fn, args, named)
+	case *object.Builtin:
+		if len(named) > 0 {
+			return newError(object.ArgumentError, "unknown argument %s: builtin functions take no named arguments", named[0].Name)
+		}
+		return fn.Fn(env, args...)
+	}
+
+	return newError(object.TypeError, "not a function: %s", object.TypeName(fn))
+}
+
+// bindArguments binds the parameters of fn in a new environment enclosed by
+// the one fn was defined in. Positional arguments fill the parameters in
+// order, with any left over going to the rest parameter; named arguments then
+// fill the parameters they name, and defaults are evaluated, in order, for
+// whatever is left. A default that returns from fn, as `?` does with an err,
+// ends the call before its body runs: what fn returns then comes back in
+// place of an error.
+func bindArguments(fn *object.Function, args []object.Object, named []object.NamedArgument) (*object.Environment, object.Object) {
+	params := fn.Parameters
+	positional := len(params)
+	if positional > 0 && params[positional-1].Rest {
+		positional--
+	}
+
+	if len(args) > positional && positional == len(params) {
+		return nil, newError(object.ArgumentError, "too many arguments in call to %s: want at most %d, got %d", describe(fn), positional, len(args))
+	}
+
+	bound := make([]object.Object, len(params))
+	for i := 0; i < len(args) && i < positional; i++ {
+		bound[i] = args[i]
+	}
+
+	for _, arg := range named {
+		i := parameterIndex(params, arg.Name)
+		switch {
+		case i < 0:
+			return nil, newError(object.ArgumentError, "unknown argument %s in call to %s", arg.Name, describe(fn))
+		case params[i].Rest:
+			return nil, newError(object.ArgumentError, "rest parameter %s of %s cannot be passed by name", arg.Name, describe(fn))
+		case bound[i] != nil:
+			return nil, newError(object.ArgumentError, "argument %s passed both by position and by name in call to %s", arg.Name, describe(fn))
+		}
+		bound[i] = arg.Value
+	}
+
+	var missing []string
+	for i, param := range params {
+		if bound[i] == nil && param.Default == nil && !param.Rest {
+			missing = append(missing, param.Target.String())
+		}
+	}
+	if len(missing) > 0 {
+		return nil, missingArguments(missing, describe(fn))
+	}
+
+	env := object.NewEnclosedEnvironment(fn.Env)
+	set := func(name *ast.Identifier, value object.Object) { env.Set(name.Value, value) }
+
+	for i, param := range params {
+		value := bound[i]
+
+		switch {
+		case param.Rest:
+			rest := []object.Object{}
+			if len(args) > positional {
+				rest = append(rest, args[positional:]...)
+			}
+			value = &object.Array{Elements: rest}
+		case value == nil:
+			value = Eval(param.Default, env)
+			if returnValue, ok := value.(*object.ReturnValue); ok {
+				return nil, returnValue.Value
+			}
+			value = unwrapReturnValue(value)
+			if isError(value) {
+				return nil, value
+			}
+		}
+
+		if err := bindPattern(param.Target, value, env, set); err != nil {
+			return nil, err
+		}
+	}
+
+	return env, nil
+}
+
+func missingArguments(missing []string, callee string) object.Object {
+	if len(missing) == 1 {
+		return newError(object.ArgumentError, "missing argument %s in call to %s", missing[0], callee)
+	}
+
+	return newError(object.ArgumentError, "missing arguments %s in call to %s", strings.Join(missing, ", "), callee)
+}
+
+func parameterIndex(params []*ast.Parameter, name string) int {
+	for i, param := range params {
+		if id, ok := param.Target.(*ast.Identifier); ok && id.Value == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// describe names a function in error messages.
+func describe(fn *object.Function) string {
+	if fn.Name == "" {
+		return "anonymous function"
+	}
+
+	return fn.Name
+}
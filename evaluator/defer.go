@@ -0,0 +1,187 @@
+package evaluator
+
+import (
+	"github.com/0xedb/intlang/ast"
+	"github.com/0xedb/intlang/object"
+	"github.com/0xedb/intlang/token"
+)
+
+// frame is the activation of a call of a user-defined function.
+// Tail calls replace the function of a frame, so one frame can stand for a
+// chain of calls.
+type frame struct {
+	function  string         // the name of the function, or <anonymous>
+	site      token.Position // where it was called
+	entry     token.Position // where the first function of the frame was called
+	elided    int            // how many functions tail calls have replaced
+	deferred  []deferredCall
+	unwinding bool          // whether the function is running its deferred calls
+	err       *object.Error // the error the function is exiting with, if any
+}
+
+// deferredCall is a call scheduled by a defer statement, with its function
+// and arguments already evaluated.
+type deferredCall struct {
+	function object.Object
+	args     []object.Object
+	named    []object.NamedArgument
+	site     token.Position
+}
+
+// calls is the stack of calls an evaluation is in the middle of. An
+// evaluation keeps it in its environments, so evaluations in different
+// environments have stacks of their own; one evaluation is not safe for
+// concurrent use.
+type calls struct {
+	frames []*frame // innermost last
+
+	// site is the position of the operation the innermost call is
+	// evaluating that may call a function: a call, or an operator or index
+	// a user-defined type may overload. It becomes the call site of the
+	// next frame pushed, and is restored when that frame is popped.
+	site token.Position
+}
+
+// callsOf returns the call stack of the evaluation env belongs to, starting
+// one if env is the first environment of an evaluation.
+func callsOf(env *object.Environment) *calls {
+	c, ok := env.Evaluation.(*calls)
+	if !ok {
+		c = &calls{}
+		env.Evaluation = c
+	}
+
+	return c
+}
+
+// reset empties the call stack before a program is evaluated, dropping any
+// frames an abandoned evaluation left behind.
+func (c *calls) reset() {
+	c.frames, c.site = nil, token.Position{}
+}
+
+// callFunction evaluates the body of fn in env, the environment its
+// parameters are bound in, and then the calls it deferred.
+//
+// A tail call to a user-defined function, made by a function with nothing
+// deferred, reuses the frame: callFunction evaluates the body of the function
+// called in place of the body that called it, so tail recursion runs in
+// constant Go stack space. The frame records the site of the last tail call
+// and how many functions it replaced, which stack traces report in place of
+// those functions.
+func callFunction(fn *object.Function, env *object.Environment) object.Object {
+	c := callsOf(env)
+	f := &frame{function: frameName(fn), site: c.site, entry: c.site}
+	c.frames = append(c.frames, f)
+	defer func() {
+		c.frames = c.frames[:len(c.frames)-1]
+		c.site = f.entry
+	}()
+
+	for {
+		result := unwrapReturnValue(Eval(fn.Body, env))
+
+		call, ok := result.(*object.TailCall)
+		if !ok {
+			return f.runDeferred(result, env)
+		}
+
+		c.site = call.Site
+		next, ok := call.Function.(*object.Function)
+		if !ok || len(f.deferred) > 0 {
+			result = applyFunction(call.Function, call.Args, call.Named, env)
+			return f.runDeferred(locate(result, call.Site, env), env)
+		}
+
+		bound, err := bindArguments(next, call.Args, call.Named)
+		if err != nil {
+			return locate(err, call.Site, env)
+		}
+		fn, env = next, bound
+		f.function, f.site = frameName(fn), call.Site
+		f.elided++
+	}
+}
+
+// frameName names fn in stack traces.
+func frameName(fn *object.Function) string {
+	if fn.Name == "" {
+		return "<anonymous>"
+	}
+
+	return fn.Name
+}
+
+// runDeferred makes the deferred calls of f, the last one deferred first,
+// once the function has finished with result. A deferred call that fails
+// replaces result with its error, and one that recovers from an error
+// replaces it with null. env is the environment the function ran in.
+func (f *frame) runDeferred(result object.Object, env *object.Environment) object.Object {
+	f.unwinding = true
+
+	for i := len(f.deferred) - 1; i >= 0; i-- {
+		f.err, _ = result.(*object.Error)
+
+		call := f.deferred[i]
+		callsOf(env).site = call.site
+		if out := applyFunction(call.function, call.args, call.named, env); isError(out) {
+			result = out
+			continue
+		}
+
+		if f.err == nil && isError(result) {
+			result = NULL
+		}
+	}
+
+	return result
+}
+
+func evalDeferStatement(node *ast.DeferStatement, env *object.Environment) object.Object {
+	frames := callsOf(env).frames
+	if len(frames) == 0 {
+		return newError(object.TypeError, "defer outside a function")
+	}
+
+	function, args, named, err := evalCall(node.Call, env)
+	if err != nil {
+		return err
+	}
+
+	f := frames[len(frames)-1]
+	f.deferred = append(f.deferred, deferredCall{function: function, args: args, named: named, site: node.Token.Pos})
+
+	return nil
+}
+
+// recoverError takes the error from the function whose deferred calls are
+// running, provided recover was called directly by a function it deferred.
+// As in Go, `defer recover()` recovers nothing, since no deferred function
+// calls recover. env is the environment recover was called in.
+func recoverError(env *object.Environment) object.Object {
+	frames := callsOf(env).frames
+	n := len(frames)
+	if n < 2 || !frames[n-2].unwinding || frames[n-1].elided > 0 {
+		return NULL
+	}
+
+	f := frames[n-2]
+	if f.err == nil {
+		return NULL
+	}
+
+	err := f.err
+	f.err = nil
+
+	return caught(err)
+}
+
+// stack returns the calls being evaluated, innermost first.
+func (c *calls) stack() []object.Frame {
+	stack := make([]object.Frame, len(c.frames))
+	for i, f := range c.frames {
+		stack[len(c.frames)-1-i] = object.Frame{Function: f.function, Site: f.site, Elided: f.elided, Entry: f.entry}
+	}
+
+	return stack
+}
@@ -0,0 +1,147 @@
+package evaluator
+
+import (
+	"github.com/0xedb/intlang/ast"
+	"github.com/0xedb/intlang/object"
+)
+
+// evalEnumStatement declares the enum, a constructor for each variant with
+// fields and a value for each variant without, all immutable.
+func evalEnumStatement(node *ast.EnumStatement, env *object.Environment) object.Object {
+	enum := &object.Enum{Name: node.Name.Value, Methods: object.Methods{}}
+	if err := declare(env, node.Name, enum, false); err != nil {
+		return err
+	}
+
+	for _, v := range node.Variants {
+		variant := &object.Variant{Enum: enum, Name: v.Name.Value}
+		enum.Variants = append(enum.Variants, variant)
+
+		var val object.Object = variant
+		if v.Fields == nil {
+			val = &object.EnumValue{Variant: variant}
+		} else {
+			variant.Fields = make([]string, len(v.Fields))
+			for i, f := range v.Fields {
+				variant.Fields[i] = f.Value
+			}
+		}
+		if err := declare(env, v.Name, val, false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// construct calls the constructor of variant, which takes its fields as
+// arguments by position or by name.
+func construct(variant *object.Variant, args []object.Object, named []object.NamedArgument) object.Object {
+	fields := variant.Fields
+	if len(args) > len(fields) {
+		return newError(object.ArgumentError, "too many arguments in call to %s: want at most %d, got %d", variant.Name, len(fields), len(args))
+	}
+
+	values := make([]object.Object, len(fields))
+	copy(values, args)
+
+	for _, arg := range named {
+		i := fieldIndex(fields, arg.Name)
+		switch {
+		case i < 0:
+			return newError(object.ArgumentError, "unknown argument %s in call to %s", arg.Name, variant.Name)
+		case values[i] != nil:
+			return newError(object.ArgumentError, "argument %s passed both by position and by name in call to %s", arg.Name, variant.Name)
+		}
+		values[i] = arg.Value
+	}
+
+	var missing []string
+	for i, value := range values {
+		if value == nil {
+			missing = append(missing, fields[i])
+		}
+	}
+	if len(missing) > 0 {
+		return missingArguments(missing, variant.Name)
+	}
+
+	return &object.EnumValue{Variant: variant, Values: values}
+}
+
+func fieldIndex(fields []string, name string) int {
+	for i, f := range fields {
+		if f == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// enumValuesEqual reports whether a and b are the same variant with equal
+// values.
+func enumValuesEqual(a, b *object.EnumValue, env *object.Environment) bool {
+	if a.Variant != b.Variant {
+		return false
+	}
+
+	for i := range a.Values {
+		if evalInfixExpression("==", a.Values[i], b.Values[i], env) != TRUE {
+			return false
+		}
+	}
+
+	return true
+}
+
+func matchVariantPattern(pattern *ast.VariantPattern, value object.Object, env *object.Environment, bind binder) object.Object {
+	v, found := env.Get(pattern.Name.Value)
+	if name := pattern.Name.Value; !found && (name == "ok" || name == "err") {
+		return matchResultPattern(pattern, value, env, bind)
+	}
+
+	var variant *object.Variant
+	switch v := v.(type) {
+	case *object.Variant:
+		variant = v
+	case *object.EnumValue:
+		variant = v.Variant
+	default:
+		return locate(newError(object.TypeError, "%s is not an enum variant", pattern.Name.Value), pattern.Token.Pos, env)
+	}
+
+	if len(pattern.Fields) != len(variant.Fields) {
+		return locate(newError(object.MatchError, "pattern %s has %d fields, but variant %s has %d",
+			pattern, len(pattern.Fields), variant.Name, len(variant.Fields)), pattern.Token.Pos, env)
+	}
+
+	ev, ok := value.(*object.EnumValue)
+	if !ok || ev.Variant != variant {
+		return newMismatch("%s does not match %s", value.Inspect(), pattern)
+	}
+
+	for i, field := range pattern.Fields {
+		if err := matchPattern(field, ev.Values[i], env, bind); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func isEnumValue(obj object.Object) bool {
+	_, ok := obj.(*object.EnumValue)
+	return ok
+}
+
+// variant returns the variant of enum named name, or nil if there is none.
+func variant(enum *object.Enum, name string) *object.Variant {
+	for _, v := range enum.Variants {
+		if v.Name == name {
+			return v
+		}
+	}
+
+	return nil
+}
@@ -1,29 +1,538 @@
 package evaluator
 
 import (
+	"fmt"
+	"unicode/utf8"
+
 	"github.com/0xedb/intlang/ast"
+	"github.com/0xedb/intlang/token"
 
 	"github.com/0xedb/intlang/object"
 )
 
-func Eval(node ast.Node) object.Object {
+var (
+	NULL  = &object.Null{}
+	TRUE  = &object.Boolean{Value: true}
+	FALSE = &object.Boolean{Value: false}
+)
+
+// Eval evaluates node in env. A program is evaluated from scratch, with no
+// calls in progress, so each program, such as each line of a REPL, starts
+// with an empty call stack. Eval keeps the call stack in env and the
+// environments it encloses, so programs evaluated in different environments
+// may run at once, but not programs that share one.
+func Eval(node ast.Node, env *object.Environment) object.Object {
 	switch node := node.(type) {
 	case *ast.Program:
-		return evalStatements(node.Statements)
+		callsOf(env).reset()
+		return evalProgram(node.Statements, env)
 	case *ast.ExpressionStatement:
-		return Eval(node.Expression)
+		return Eval(node.Expression, env)
+	case *ast.BlockStatement:
+		return evalBlockStatement(node, env)
+	case *ast.ReturnStatement:
+		if node.ReturnValue == nil {
+			return &object.ReturnValue{Value: NULL}
+		}
+		val := Eval(node.ReturnValue, env)
+		if isError(val) {
+			return val
+		}
+		return &object.ReturnValue{Value: val}
+	case *ast.AtStatement:
+		val := Eval(node.Value, env)
+		if isError(val) {
+			return val
+		}
+		var redeclared object.Object
+		err := bindPattern(node.Target, val, env, func(name *ast.Identifier, val object.Object) {
+			if err := declare(env, name, val, node.Mutable); err != nil && redeclared == nil {
+				redeclared = err
+			}
+		})
+		if err != nil {
+			return locate(err, node.Token.Pos, env)
+		}
+		if redeclared != nil {
+			return redeclared
+		}
+	case *ast.FunctionStatement:
+		// Declared by hoistFunctions before the enclosing block ran.
+		return nil
+	case *ast.EnumStatement:
+		return evalEnumStatement(node, env)
+	case *ast.StructStatement:
+		return evalStructStatement(node, env)
+	case *ast.ImplStatement:
+		return locate(evalImplStatement(node, env), node.Token.Pos, env)
+	case *ast.ThrowStatement:
+		return evalThrowStatement(node, env)
+	case *ast.DeferStatement:
+		return locate(evalDeferStatement(node, env), node.Token.Pos, env)
+	case *ast.WhileStatement:
+		return evalWhileStatement(node, env)
+	case *ast.ForStatement:
+		return locate(evalForStatement(node, env), node.Token.Pos, env)
+	case *ast.BranchStatement:
+		label := ""
+		if node.Label != nil {
+			label = node.Label.Value
+		}
+		if node.Token.Token == token.BREAK {
+			return &object.Break{Label: label}
+		}
+		return &object.Continue{Label: label}
+
 	case *ast.IntegralExpression:
 		return &object.Integer{Value: node.Value}
+	case *ast.StringLiteral:
+		return &object.String{Value: node.Value}
+	case *ast.Boolean:
+		return nativeBoolToBooleanObject(node.Value)
+	case *ast.PrefixExpression:
+		right := Eval(node.Right, env)
+		if isError(right) {
+			return right
+		}
+		callsOf(env).site = node.Token.Pos
+		return locate(evalPrefixExpression(node.Operator, right, env), node.Token.Pos, env)
+	case *ast.InfixExpression:
+		left := Eval(node.Left, env)
+		if isError(left) {
+			return left
+		}
+		// The right operand of ?? is only evaluated if the left is null.
+		if node.Operator == "??" {
+			if left != NULL {
+				return left
+			}
+			return Eval(node.Right, env)
+		}
+		right := Eval(node.Right, env)
+		if isError(right) {
+			return right
+		}
+		callsOf(env).site = node.Token.Pos
+		return locate(evalInfixExpression(node.Operator, left, right, env), node.Token.Pos, env)
+	case *ast.IfExpression:
+		return evalIfExpression(node, env)
+	case *ast.MatchExpression:
+		return locate(evalMatchExpression(node, env), node.Token.Pos, env)
+	case *ast.TryExpression:
+		return evalTryExpression(node, env)
+	case *ast.PropagateExpression:
+		return locate(evalPropagateExpression(node, env), node.Token.Pos, env)
+	case *ast.Identifier:
+		return locate(evalIdentifier(node, env), node.Token.Pos, env)
+	case *ast.FunctionLiteral:
+		return newFunction(node, env)
+	case *ast.Placeholder:
+		// Evaluated as an argument, a placeholder leaves a hole for partial
+		// to find.
+		return nil
+	case *ast.CallExpression:
+		return locate(evalCallExpression(node, env), node.Token.Pos, env)
+	case *ast.ArrayLiteral:
+		elements := evalExpressions(node.Elements, env)
+		if len(elements) == 1 && isError(elements[0]) {
+			return locate(elements[0], node.Token.Pos, env)
+		}
+		return &object.Array{Elements: elements}
+	case *ast.IndexExpression:
+		left, done := evalOperand(node.Left, node.Optional, env)
+		if done {
+			return left
+		}
+		index := Eval(node.Index, env)
+		if isError(index) {
+			return index
+		}
+		callsOf(env).site = node.Token.Pos
+		return locate(evalIndexExpression(left, index, env), node.Token.Pos, env)
+	case *ast.HashLiteral:
+		return locate(evalHashLiteral(node, env), node.Token.Pos, env)
+	case *ast.StructLiteral:
+		return locate(evalStructLiteral(node, env), node.Token.Pos, env)
+	case *ast.MemberExpression:
+		return locate(evalMemberExpression(node, env), node.Token.Pos, env)
+	case *ast.AssignExpression:
+		return locate(evalAssignExpression(node, env), node.Token.Pos, env)
 	}
 
 	return nil
 }
 
-func evalStatements(stmts []ast.Statement) object.Object {
+func evalProgram(stmts []ast.Statement, env *object.Environment) object.Object {
+	if err := hoistFunctions(stmts, env); err != nil {
+		return err
+	}
+
 	var result object.Object
 	for _, statement := range stmts {
-		result = Eval(statement)
+		result = Eval(statement, env)
+
+		switch result := result.(type) {
+		case *object.ReturnValue:
+			return result.Value
+		case *object.Error:
+			return result
+		}
+	}
+
+	return result
+}
+
+// evalBlockStatement evaluates statements until one of them produces a value
+// that must unwind further: a return, an error, or a break or continue.
+func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) object.Object {
+	if err := hoistFunctions(block.Statements, env); err != nil {
+		return err
+	}
+
+	var result object.Object
+	for _, statement := range block.Statements {
+		result = Eval(statement, env)
+
+		if result != nil {
+			switch result.Type() {
+			case object.RETURN_VALUE_OBJ, object.ERROR_OBJ, object.BREAK_OBJ, object.CONTINUE_OBJ:
+				return result
+			}
+		}
+	}
+
+	return result
+}
+
+// hoistFunctions declares every function declared by name among stmts before
+// any of them runs, so that functions can be called before their declaration
+// and can refer to each other.
+func hoistFunctions(stmts []ast.Statement, env *object.Environment) object.Object {
+	for _, stmt := range stmts {
+		if decl, ok := stmt.(*ast.FunctionStatement); ok {
+			if err := declare(env, decl.Name, newFunction(decl.Function, env), false); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// declare binds name immutably, or mutably if mutable is set, in the scope
+// env, where it must not be declared already.
+func declare(env *object.Environment, name *ast.Identifier, val object.Object, mutable bool) object.Object {
+	if b, ok := env.Declare(name.Value, val, mutable, name.Token.Pos); !ok {
+		return locate(newError(object.NameError, "%s redeclared in this block (previous declaration at %s)", name.Value, b.Pos), name.Token.Pos, env)
+	}
+
+	return nil
+}
+
+func newFunction(node *ast.FunctionLiteral, env *object.Environment) *object.Function {
+	return &object.Function{Name: node.Name, Parameters: node.Parameters, Body: node.Body, Env: env}
+}
+
+func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object {
+	if val, ok := env.Get(node.Value); ok {
+		return val
+	}
+
+	if builtin, ok := builtins[node.Value]; ok {
+		return builtin
+	}
+
+	return newError(object.NameError, "identifier not found: "+node.Value)
+}
+
+func evalPrefixExpression(operator string, right object.Object, env *object.Environment) object.Object {
+	switch operator {
+	case "!":
+		return nativeBoolToBooleanObject(!isTruthy(right))
+	case "-":
+		if method := methodOf(right, "__neg__"); method != nil {
+			return applyFunction(method, []object.Object{right}, nil, env)
+		}
+		if right.Type() != object.INTEGER_OBJ {
+			return newError(object.TypeError, "unknown operator: -%s", object.TypeName(right))
+		}
+		return &object.Integer{Value: -right.(*object.Integer).Value}
+	}
+
+	return newError(object.TypeError, "unknown operator: %s%s", operator, object.TypeName(right))
+}
+
+func evalInfixExpression(operator string, left, right object.Object, env *object.Environment) object.Object {
+	if result, ok := evalOperatorMethod(operator, left, right, env); ok {
+		return result
+	}
+
+	switch {
+	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
+		return evalIntegerInfixExpression(operator, left, right)
+	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
+		return evalStringInfixExpression(operator, left, right)
+	case (operator == "==" || operator == "!=") && left.Type() == object.RESULT_OBJ && right.Type() == object.RESULT_OBJ:
+		equal := resultsEqual(left.(*object.Result), right.(*object.Result), env)
+		if isError(equal) {
+			return equal
+		}
+		return nativeBoolToBooleanObject(isTruthy(equal) == (operator == "=="))
+	case (operator == "==" || operator == "!=") && isEnumValue(left) && isEnumValue(right):
+		a, b := left.(*object.EnumValue), right.(*object.EnumValue)
+		return nativeBoolToBooleanObject(enumValuesEqual(a, b, env) == (operator == "=="))
+	case operator == "==":
+		return nativeBoolToBooleanObject(left == right)
+	case operator == "!=":
+		return nativeBoolToBooleanObject(left != right)
+	case object.TypeName(left) != object.TypeName(right):
+		return newError(object.TypeError, "type mismatch: %s %s %s", object.TypeName(left), operator, object.TypeName(right))
+	}
+
+	return newError(object.TypeError, "unknown operator: %s %s %s", object.TypeName(left), operator, object.TypeName(right))
+}
+
+func evalIntegerInfixExpression(operator string, left, right object.Object) object.Object {
+	leftVal := left.(*object.Integer).Value
+	rightVal := right.(*object.Integer).Value
+
+	switch operator {
+	case "+":
+		return &object.Integer{Value: leftVal + rightVal}
+	case "-":
+		return &object.Integer{Value: leftVal - rightVal}
+	case "*":
+		return &object.Integer{Value: leftVal * rightVal}
+	case "/":
+		if rightVal == 0 {
+			return newError(object.ArithmeticError, "division by zero")
+		}
+		return &object.Integer{Value: leftVal / rightVal}
+	case "%":
+		if rightVal == 0 {
+			return newError(object.ArithmeticError, "division by zero")
+		}
+		return &object.Integer{Value: leftVal % rightVal}
+	case "<":
+		return nativeBoolToBooleanObject(leftVal < rightVal)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal)
+	case "..":
+		return &object.Range{Start: leftVal, End: rightVal}
+	}
+
+	return newError(object.TypeError, "unknown operator: %s %s %s", object.TypeName(left), operator, object.TypeName(right))
+}
+
+func evalStringInfixExpression(operator string, left, right object.Object) object.Object {
+	leftVal := left.(*object.String).Value
+	rightVal := right.(*object.String).Value
+
+	switch operator {
+	case "+":
+		return &object.String{Value: leftVal + rightVal}
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal)
 	}
-	
+
+	return newError(object.TypeError, "unknown operator: %s %s %s", object.TypeName(left), operator, object.TypeName(right))
+}
+
+func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Object {
+	condition := Eval(ie.Condition, env)
+	if isError(condition) {
+		return condition
+	}
+
+	if isTruthy(condition) {
+		return Eval(ie.Consequence, env)
+	} else if ie.Alternative != nil {
+		return Eval(ie.Alternative, env)
+	}
+
+	return NULL
+}
+
+func evalIndexExpression(left, index object.Object, env *object.Environment) object.Object {
+	if method := methodOf(left, "__index__"); method != nil {
+		return applyFunction(method, []object.Object{left, index}, nil, env)
+	}
+
+	switch {
+	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
+		elements := left.(*object.Array).Elements
+		i := index.(*object.Integer).Value
+		if i < 0 || i >= int64(len(elements)) {
+			return NULL
+		}
+		return elements[i]
+	case left.Type() == object.HASH_OBJ:
+		hash := left.(*object.Hash)
+		key, err := hashKey(hash, index, env)
+		if err != nil {
+			return err
+		}
+		pair, ok := hash.Pairs[key]
+		if !ok {
+			return NULL
+		}
+		return pair.Value
+	}
+
+	return newError(object.TypeError, "index operator not supported: %s", object.TypeName(left))
+}
+
+func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Object {
+	hash := object.NewHash()
+
+	for _, pair := range node.Pairs {
+		if spread, ok := pair.Key.(*ast.SpreadExpression); ok {
+			value := Eval(spread.Value, env)
+			if isError(value) {
+				return value
+			}
+			other, ok := value.(*object.Hash)
+			if !ok {
+				return newError(object.TypeError, "cannot spread %s into a hash literal: not a HASH", object.TypeName(value))
+			}
+			for _, k := range other.Keys {
+				pair := other.Pairs[k]
+				slot, err := hashKey(hash, pair.Key, env)
+				if err != nil {
+					return err
+				}
+				hash.Put(slot, pair.Key, pair.Value)
+			}
+			continue
+		}
+
+		key := Eval(pair.Key, env)
+		if isError(key) {
+			return key
+		}
+		callsOf(env).site = node.Token.Pos
+		slot, err := hashKey(hash, key, env)
+		if err != nil {
+			return err
+		}
+
+		value := Eval(pair.Value, env)
+		if isError(value) {
+			return value
+		}
+		hash.Put(slot, key, value)
+	}
+
+	return hash
+}
+
+// evalExpressions evaluates a list of array elements or call arguments,
+// expanding spread expressions in place. An error is returned as the only
+// element of the result.
+func evalExpressions(exps []ast.Expression, env *object.Environment) []object.Object {
+	var result []object.Object
+
+	for _, e := range exps {
+		spread, isSpread := e.(*ast.SpreadExpression)
+		if isSpread {
+			e = spread.Value
+		}
+
+		evaluated := Eval(e, env)
+		if isError(evaluated) {
+			return []object.Object{evaluated}
+		}
+
+		if !isSpread {
+			result = append(result, evaluated)
+			continue
+		}
+
+		_, isHash := evaluated.(*object.Hash)
+		iterated := iterate(evaluated, func(key, value object.Object) bool {
+			if isHash {
+				value = key
+			}
+			result = append(result, value)
+			return true
+		})
+		if !iterated {
+			return []object.Object{newError(object.TypeError, "cannot spread %s: not iterable", object.TypeName(evaluated))}
+		}
+	}
+
 	return result
 }
+
+func unwrapReturnValue(obj object.Object) object.Object {
+	if returnValue, ok := obj.(*object.ReturnValue); ok {
+		return returnValue.Value
+	}
+	if obj == nil {
+		return NULL
+	}
+
+	return obj
+}
+
+func nativeBoolToBooleanObject(input bool) *object.Boolean {
+	if input {
+		return TRUE
+	}
+
+	return FALSE
+}
+
+func isTruthy(obj object.Object) bool {
+	switch obj {
+	case NULL, FALSE:
+		return false
+	}
+
+	return true
+}
+
+func newError(kind object.ErrorKind, format string, a ...interface{}) *object.Error {
+	return &object.Error{Message: fmt.Sprintf(format, a...), Kind: kind}
+}
+
+// locate records pos as where obj was raised if it is an error that does not
+// know yet, along with the calls being evaluated. Errors are located by the
+// innermost node they unwind through that calls locate, which is always in
+// the call that raised them.
+func locate(obj object.Object, pos token.Position, env *object.Environment) object.Object {
+	if err, ok := obj.(*object.Error); ok && err.Pos == (token.Position{}) {
+		err.Pos = pos
+		err.Frames = callsOf(env).stack()
+	}
+
+	return obj
+}
+
+// isError reports whether obj must abandon the expression being evaluated:
+// an error, or a return from the enclosing function, which `?` can make in
+// the middle of an expression.
+func isError(obj object.Object) bool {
+	if obj == nil {
+		return false
+	}
+
+	switch obj.Type() {
+	case object.ERROR_OBJ, object.RETURN_VALUE_OBJ:
+		return true
+	}
+
+	return false
+}
+
+// runeLen is the length of a string as seen by intLANG programs, which
+// iterate over strings by character rather than by byte.
+func runeLen(s string) int {
+	return utf8.RuneCountInString(s)
+}
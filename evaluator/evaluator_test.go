@@ -0,0 +1,832 @@
+package evaluator
+
+import (
+	"runtime/debug"
+	"sync"
+	"testing"
+
+	"github.com/0xedb/intlang/lexer"
+	"github.com/0xedb/intlang/object"
+	"github.com/0xedb/intlang/parser"
+	"github.com/0xedb/intlang/token"
+)
+
+func testEval(t *testing.T, input string) object.Object {
+	t.Helper()
+
+	return testEvalIn(t, input, object.NewEnvironment())
+}
+
+func testEvalIn(t *testing.T, input string, env *object.Environment) object.Object {
+	t.Helper()
+
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+
+	if errs := p.Errors(); len(errs) != 0 {
+		t.Fatalf("unexpected parser errors for %q: %v", input, errs)
+	}
+
+	return Eval(program, env)
+}
+
+// expectInspect evaluates each input and compares what it evaluates to with
+// the expected Inspect output, which also covers errors ("ERROR: ...").
+func expectInspect(t *testing.T, tests []struct{ input, expect string }) {
+	t.Helper()
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			got := testEval(t, test.input)
+			if got == nil {
+				t.Fatalf("Wanted: %s, Got: nil", test.expect)
+			}
+			if got.Inspect() != test.expect {
+				t.Fatalf("Wanted: %s, Got: %s", test.expect, got.Inspect())
+			}
+		})
+	}
+}
+
+// expectTrace evaluates each input, which must fail, and compares the stack
+// trace of the error with the expected one.
+func expectTrace(t *testing.T, tests []struct{ input, expect string }) {
+	t.Helper()
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			err, ok := testEval(t, test.input).(*object.Error)
+			if !ok {
+				t.Fatalf("Wanted an error")
+			}
+			if got := err.Trace(); got != test.expect {
+				t.Fatalf("Wanted:\n%s\nGot:\n%s", test.expect, got)
+			}
+		})
+	}
+}
+
+func TestEvalExpressions(t *testing.T) {
+	expectInspect(t, []struct{ input, expect string }{
+		{"5", "5"},
+		{"-0x10 + 2 * 3", "-10"},
+		{"(5 + 10 * 2 + 15 / 3) * 2 + -10", "50"},
+		{"1 < 2 == true", "true"},
+		{"!(1 != 1)", "true"},
+		{"!5", "false"},
+		{`"foo" + "bar" == "foobar"`, "true"},
+		{"if (1 > 2) { 10 } el { 20 }", "20"},
+		{"if (false) { 10 }", "null"},
+		{"[1, 2 * 2, 3][1]", "4"},
+		{"[1, 2, 3][3]", "null"},
+		{`{"a": 1, true: 2, 3: 4}`, "{a: 1, true: 2, 3: 4}"},
+		{`{"a": 1}["a"]`, "1"},
+		{"2..5", "2..5"},
+	})
+}
+
+func TestEvalStatements(t *testing.T) {
+	expectInspect(t, []struct{ input, expect string }{
+		{"@ a = 5\n@ b = a * 2\nb", "10"},
+		{"if (true) { if (true) { ret 10 }\n ret 1 }", "10"},
+		{"@ add = fn(x, y) { x + y }\nadd(5, add(5, 5))", "15"},
+		{"@ adder = fn(x) { fn(y) { x + y } }\n@ two = adder(2)\ntwo(3)", "5"},
+		{"@ f = fn() { ret }\nf()", "null"},
+		{`len("héllo") + len([1, 2]) + len(0..10)`, "17"},
+		{"push(rest([1, 2, 3]), 4)", "[2, 3, 4]"},
+	})
+}
+
+func TestErrorHandling(t *testing.T) {
+	expectInspect(t, []struct{ input, expect string }{
+		{"5 + true; 5", "ERROR: type mismatch: INTEGER + BOOLEAN"},
+		{"-true", "ERROR: unknown operator: -BOOLEAN"},
+		{"if (10 > 1) { true + false; 10 }", "ERROR: unknown operator: BOOLEAN + BOOLEAN"},
+		{`"a" - "b"`, "ERROR: unknown operator: STRING - STRING"},
+		{"foobar", "ERROR: identifier not found: foobar"},
+		{"1 / 0", "ERROR: division by zero"},
+		{`{fn(x) { x }: 1}`, "ERROR: unusable as hash key: FUNCTION"},
+		{"fn(x) { x }(1, 2)", "ERROR: too many arguments in call to anonymous function: want at most 1, got 2"},
+		{"5()", "ERROR: not a function: INTEGER"},
+	})
+}
+
+func TestWhileLoops(t *testing.T) {
+	expectInspect(t, []struct{ input, expect string }{
+		{"@ mut i = 0\nwhile (i < 5) { i += 1 }\ni", "5"},
+		{"while (false) { 1 }", "null"},
+		{"@ mut i = 0\nwhile (true) { i += 1\n if (i > 9) { break } }\ni", "10"},
+		{"@ mut i = 0\n@ mut n = 0\nwhile (i < 10) {\ni += 1\nif (i > 3) { continue }\nn += i\n}\nn", "6"},
+		{"@ f = fn() { while (true) { ret 7 } }\nf()", "7"},
+		{"while (true) { 1 + true }", "ERROR: type mismatch: INTEGER + BOOLEAN"},
+	})
+}
+
+func TestForLoops(t *testing.T) {
+	expectInspect(t, []struct{ input, expect string }{
+		{"@ mut s = 0\nfor (x in [1, 2, 3]) { s += x }\ns", "6"},
+		{"@ mut s = 0\nfor (i, x in [10, 20]) { s += i * x }\ns", "20"},
+		{`@ mut s = ""` + "\nfor (c in \"héllo\") { s = c + s }\ns", "olléh"},
+		{`@ mut s = 0` + "\nfor (i, c in \"日本語\") { s += i }\ns", "3"},
+		{`@ mut s = ""` + "\nfor (k in {\"a\": 1, \"b\": 2}) { s += k }\ns", "ab"},
+		{`@ mut s = 0` + "\nfor (k, v in {\"a\": 1, \"b\": 2}) { s += v }\ns", "3"},
+		{"@ mut s = 0\nfor (i in 0..1000000) { s += i }\ns", "499999500000"},
+		{"@ mut s = 0\nfor (i in 5..0) { s += 1 }\ns", "0"},
+		{"@ mut s = 0\nfor (i in 0..10) { if (i == 3) { break }\n s += i }\ns", "3"},
+		{"@ mut s = 0\nfor (i in 0..10) { if (i < 8) { continue }\n s += i }\ns", "17"},
+		{"for (x in 5) { x }", "ERROR: cannot iterate over INTEGER"},
+	})
+}
+
+func TestLabeledLoops(t *testing.T) {
+	expectInspect(t, []struct{ input, expect string }{
+		{`@ mut pairs = 0
+outer: for (i in 0..10) {
+	for (j in 0..10) {
+		if (j > i) { continue outer }
+		if (i == 5) { break outer }
+		pairs += 1
+	}
+}
+pairs`, "15"},
+		{`@ mut n = 0
+outer: while (true) {
+	while (true) {
+		n += 1
+		break outer
+	}
+	n = 100
+}
+n`, "1"},
+		{`@ mut n = 0
+for (i in 0..3) {
+	inner: for (j in 0..3) { break inner }
+	n += 1
+}
+n`, "3"},
+	})
+}
+
+func TestAssignment(t *testing.T) {
+	expectInspect(t, []struct{ input, expect string }{
+		{"@ mut x = 1\nx = 2\nx", "2"},
+		{"@ mut x = 1\nx = x + 1", "2"},
+		{"@ mut a = 1\n@ mut b = 2\na = b = 5\na + b", "10"},
+		{"@ mut x = 10\nx += 5\nx -= 3\nx *= 2\nx /= 4\nx %= 4\nx", "2"},
+		{`@ mut s = "a"` + "\ns += \"b\"\ns", "ab"},
+		{"@ mut i = 0\nwhile (i < 5) { i += 1 }\ni", "5"},
+		{"@ mut n = 0\n@ inc = fn() { n += 1 }\ninc()\ninc()\nn", "2"},
+		{"@ n = 0\n@ f = fn() { @ mut n = 5\nn = 6 }\nf()\nn", "0"},
+		{"@ xs = [1, 2, 3]\nxs[1] = 20\nxs[2] += 10\nxs", "[1, 20, 13]"},
+		{`@ h = {"a": 1}` + "\nh[\"b\"] = 2\nh[\"a\"] += 10\nh", "{a: 11, b: 2}"},
+		{"@ m = [[1], [2]]\nm[1][0] = 5\nm", "[[1], [5]]"},
+		{"y = 1", "ERROR: cannot assign to undeclared identifier: y"},
+		{"@ mut x = 1\nx += true", "ERROR: type mismatch: INTEGER + BOOLEAN"},
+		{"@ xs = [1]\nxs[1] = 2", "ERROR: index out of range: 1 with length 1"},
+		{`@ s = "abc"` + "\ns[0] = \"x\"", "ERROR: index assignment not supported: STRING"},
+		{"@ mut x = 1\nx %= 0", "ERROR: division by zero"},
+	})
+}
+
+func TestImmutableBindings(t *testing.T) {
+	expectInspect(t, []struct{ input, expect string }{
+		{"@ x = 1\nx = 2", "ERROR: cannot assign to immutable x (declared at 1:3; use `@ mut` to allow reassignment)"},
+		{"@ f = fn() { g += 1 }\n@ g = 0\nf()", "ERROR: cannot assign to immutable g (declared at 2:3; use `@ mut` to allow reassignment)"},
+		{"@ f = fn() { g += 1 }\n@ mut g = 0\nf()\ng", "1"},
+		{"@ x = 1\n@ x = 2\nx", "ERROR: x redeclared in this block (previous declaration at 1:3)"},
+		{"@ x = 1\n@ f = fn() { x }\n@ x = 2\nf()", "ERROR: x redeclared in this block (previous declaration at 1:3)"},
+		{"@ a = 1\n@ [b, a] = [1, 2]", "ERROR: a redeclared in this block (previous declaration at 1:3)"},
+		{"fn f() { 1 }\n@ f = 2", "ERROR: f redeclared in this block (previous declaration at 1:4)"},
+		{"struct P { x }\nenum E { P }", "ERROR: P redeclared in this block (previous declaration at 1:8)"},
+		{"@ x = 1\nif (true) { @ x = 2 }", "ERROR: x redeclared in this block (previous declaration at 1:3)"},
+		{"@ x = 1\n@ f = fn() { @ x = 2; x }\n[f(), x]", "[2, 1]"},
+		{"@ xs = [1]\nxs[0] = 2\nxs", "[2]"},
+		{"@ f = fn(a) { a = a + 1\na }\nf(1)", "2"},
+		{"@ x = 1\nfor (x in 0..3) { x = 0 }\nx", "1"},
+		{"@ x = 1\nfor (x in [1, 2]) { }\nx", "1"},
+		{"for (i in 0..2) { @ y = i }\ny", "ERROR: identifier not found: y"},
+		{"@ mut i = 0\n@ x = 1\nwhile (i < 2) { @ mut x = i; x += 10; i += 1 }\n[i, x]", "[2, 1]"},
+	})
+
+	expectTrace(t, []struct{ input, expect string }{
+		{"@ x = 1\nfn f() { x = 2 }\nf()", `error: cannot assign to immutable x (declared at 1:3; use ` + "`@ mut`" + ` to allow reassignment) [AssignmentError]
+
+f(...)
+	2:12
+<main>
+	3:2
+`},
+	})
+}
+
+func TestFunctionDeclarations(t *testing.T) {
+	expectInspect(t, []struct{ input, expect string }{
+		{"fn add(a, b) { a + b }\nadd(1, 2)", "3"},
+		{"@ r = twice(4)\nfn twice(x) { x * 2 }\nr", "8"},
+		{`fn even(n) { if (n == 0) { true } el { odd(n - 1) } }
+fn odd(n) { if (n == 0) { false } el { even(n - 1) } }
+[even(10), odd(7), even(3)]`, "[true, true, false]"},
+		{"fn fact(n) { if (n < 2) { ret 1 }\nfact(n - 1) * n }\nfact(10)", "3628800"},
+		{"@ f = fn() { ret helper() + 1\nfn helper() { 41 } }\nf()", "42"},
+		{"fn greet(name) { \"hi \" + name }\ngreet", `fn greet(name) { ("hi " + name) }`},
+		{"fn(x) { x }", "fn(x) { x }"},
+		{"fn f() { 1 }\nf = 2", "ERROR: cannot assign to immutable f (declared at 1:4; use `@ mut` to allow reassignment)"},
+	})
+}
+
+func TestCallArguments(t *testing.T) {
+	expectInspect(t, []struct{ input, expect string }{
+		{"fn f(x, y = 10) { [x, y] }\nf(1)", "[1, 10]"},
+		{"fn f(x, y = 10) { [x, y] }\nf(1, 2)", "[1, 2]"},
+		{"fn f(x, y = x * 2) { [x, y] }\nf(3)", "[3, 6]"},
+		{"fn f(head, ...tail) { [head, tail] }\nf(1, 2, 3)", "[1, [2, 3]]"},
+		{"fn f(head, ...tail) { [head, tail] }\nf(1)", "[1, []]"},
+		{"fn f(x, y) { x - y }\nf(y: 1, x: 3)", "2"},
+		{"fn f(x, y = 2, z = 3) { [x, y, z] }\nf(1, z: 30)", "[1, 2, 30]"},
+		{"@ mut n = 0\nfn f(x = (n += 1)) { x }\n[f(), f(), f(7), n]", "[1, 2, 7, 2]"},
+
+		{"fn f(x, y) { x }\nf(1)", "ERROR: missing argument y in call to f"},
+		{"fn f(x, y) { x }\nf()", "ERROR: missing arguments x, y in call to f"},
+		{"fn f(x) { x }\nf(1, 2)", "ERROR: too many arguments in call to f: want at most 1, got 2"},
+		{"fn f(x) { x }\nf(z: 1)", "ERROR: unknown argument z in call to f"},
+		{"fn f(x) { x }\nf(1, x: 2)", "ERROR: argument x passed both by position and by name in call to f"},
+		{"fn f(...xs) { xs }\nf(xs: 1)", "ERROR: rest parameter xs of f cannot be passed by name"},
+		{"fn f(x = 1 / 0) { x }\nf()", "ERROR: division by zero"},
+		{"len(x: [])", "ERROR: unknown argument x: builtin functions take no named arguments"},
+	})
+}
+
+func TestSpread(t *testing.T) {
+	expectInspect(t, []struct{ input, expect string }{
+		{"@ xs = [1, 2]\n[0, ...xs, 3]", "[0, 1, 2, 3]"},
+		{"[...0..3, ...\"ab\", ...[]]", "[0, 1, 2, a, b]"},
+		{"[...{\"a\": 1, \"b\": 2}]", "[a, b]"},
+		{"fn add(a, b, c) { a + b + c }\n@ args = [2, 3]\nadd(1, ...args)", "6"},
+		{"fn f(...xs) { xs }\nf(...[1, 2], 3, ...[4])", "[1, 2, 3, 4]"},
+		{"len(...[[1, 2, 3]])", "3"},
+		{`@ defaults = {"a": 1, "b": 2}
+{...defaults, "b": 20, "c": 3}`, "{a: 1, b: 20, c: 3}"},
+		{`{"b": 20, ...{"a": 1, "b": 2}}`, "{b: 2, a: 1}"},
+
+		{"[...5]", "ERROR: cannot spread INTEGER: not iterable"},
+		{"fn f(...xs) { xs }\nf(...true)", "ERROR: cannot spread BOOLEAN: not iterable"},
+		{"{...[1, 2]}", "ERROR: cannot spread ARRAY into a hash literal: not a HASH"},
+		{"[...missing]", "ERROR: identifier not found: missing"},
+	})
+}
+
+func TestDestructuring(t *testing.T) {
+	expectInspect(t, []struct{ input, expect string }{
+		{"@ [a, b, ...rest] = [1, 2, 3, 4]\n[a, b, rest]", "[1, 2, [3, 4]]"},
+		{"@ [a, ...rest] = [1]\nrest", "[]"},
+		{"@ [a, b = a + 1] = [1]\nb", "2"},
+		{`@ person = {"name": "ada", "age": 36}
+@ {name, age: years} = person
+[name, years]`, "[ada, 36]"},
+		{`@ {name, role = "guest"} = {"name": "bob"}
+role`, "guest"},
+		{`@ {pos: [x, y]} = {"pos": [3, 4], "id": 1}
+x * y`, "12"},
+		{"@ [a, b] = [1, 2]\na = 3", "ERROR: cannot assign to immutable a (declared at 1:4; use `@ mut` to allow reassignment)"},
+		{"@ mut [a, b] = [1, 2]\na = 3\n[a, b]", "[3, 2]"},
+		{"fn f([a, b], {c}) { a + b + c }\nf([1, 2], {\"c\": 3})", "6"},
+		{"fn f([a, b] = [1, 2]) { a - b }\nf()", "-1"},
+		{"fn f({x, y = 0}) { [x, y] }\nf({\"x\": 1})", "[1, 0]"},
+
+		{"@ [a, b] = [1]", "ERROR: cannot destructure array of length 1 with [a, b]: missing element 1"},
+		{"@ [a] = [1, 2]", "ERROR: cannot destructure array of length 2 with [a]: too many elements"},
+		{"@ [a] = 1", "ERROR: cannot destructure INTEGER with [a]"},
+		{`@ {name, age} = {"name": "ada"}`, `ERROR: cannot destructure hash with {name, age}: missing key "age"`},
+		{"@ {name} = [1]", "ERROR: cannot destructure ARRAY with {name}"},
+		{"fn f([a, b]) { a }\nf([1, 2, 3])", "ERROR: cannot destructure array of length 3 with [a, b]: too many elements"},
+		{"fn f([a], b) { a }\nf(1)", "ERROR: missing argument b in call to f"},
+		{"@ [a = 1 / 0] = []", "ERROR: division by zero"},
+	})
+}
+
+func TestMatchExpressions(t *testing.T) {
+	expectInspect(t, []struct{ input, expect string }{
+		{`fn name(n) { match (n) { 0 => "zero", 1 | 2 => "few", -1 => "minus one", _ => "many" } }
+[name(0), name(2), name(-1), name(7)]`, "[zero, few, minus one, many]"},
+		{`match ("b") { "a" => 1, "b" => 2 }`, "2"},
+		{"match (5) { n if n > 10 => \"big\", n => n * 2 }", "10"},
+		{"match ([1, 2, 3]) { [] => 0, [x] => x, [x, ...rest] => rest }", "[2, 3]"},
+		{"match ([0, 4]) { [0, y] => y, [x, 0] => x }", "4"},
+		{`@ shape = {"kind": "circle", "r": 2}
+match (shape) {
+	{kind: "square", side} => side * side
+	{kind: "circle", r} => 3 * r * r
+}`, "12"},
+		{"match (true) { false => 0, true => { @ x = 40\nx + 2 } }", "42"},
+		{"match ([1, 2]) { [a, b] | [a, b, _] => a + b }", "3"},
+		{"match ([5, 2]) { [x, 1] | [_, x] => x }", "2"},
+		{"match ({}) { {x = 5} => x }", "5"},
+		{"match (1) { \"1\" => 0, 1 => 1 }", "1"},
+		{"@ x = 1\nmatch (2) { x => x }\nx", "1"},
+		{"fn f(x) { match (x) { 0 => { ret \"early\" }, _ => 1 }\n\"late\" }\n[f(0), f(1)]", "[early, late]"},
+
+		{"match (3) { 1 => 0, 2 => 0 }", "ERROR: no arm of match matches 3"},
+		{"try { match (3) { 1 => 0 } } catch (e) { [e.message, e.position] }", "[no arm of match matches 3, 1:7]"},
+		{"match (1) { n if n + true => 0 }", "ERROR: type mismatch: INTEGER + BOOLEAN"},
+		{"match (1) { a => { a = 2 } }", "ERROR: cannot assign to immutable a (declared at 1:13; use `@ mut` to allow reassignment)"},
+	})
+}
+
+func TestEnums(t *testing.T) {
+	const shape = "enum Shape { Circle(r), Rect(w, h), Empty }\n"
+	expectInspect(t, []struct{ input, expect string }{
+		{shape + "[Circle(2), Rect(1, h: 3), Empty]", "[Circle(2), Rect(1, 3), Empty]"},
+		{shape + "Shape", "enum Shape { Circle(r), Rect(w, h), Empty }"},
+		{shape + "Rect", "variant Shape.Rect(w, h)"},
+		{shape + "[Circle(1) == Circle(1), Circle(1) == Circle(2), Empty == Empty, Empty != Circle(1)]", "[true, false, true, true]"},
+		{shape + "[Rect(1, Circle(2)) == Rect(1, Circle(2)), Circle(1) == 1]", "[true, false]"},
+		{shape + `fn area(s) {
+	match (s) {
+		Circle(r) => 3 * r * r
+		Rect(w, h) => w * h
+		Empty => 0
+	}
+}
+[area(Circle(2)), area(Rect(2, 5)), area(Empty)]`, "[12, 10, 0]"},
+		{shape + "@ Rect(w, h) = Rect(4, 5)\nw * h", "20"},
+		{shape + "fn width(Rect(w, _)) { w }\nwidth(Rect(7, 1))", "7"},
+		{"enum Option { Some(v), None }\nmatch ([Some(1), None]) { [Some(a), None] => a }", "1"},
+
+		{shape + "Circle()", "ERROR: missing argument r in call to Circle"},
+		{shape + "Rect(1, 2, 3)", "ERROR: too many arguments in call to Rect: want at most 2, got 3"},
+		{shape + "Rect(1, d: 2)", "ERROR: unknown argument d in call to Rect"},
+		{shape + "Empty()", "ERROR: not a function: Shape"},
+		{shape + "@ Circle(r) = Empty", "ERROR: Empty does not match Circle(r)"},
+		{shape + "match (Empty) { Rect(w) => w }", "ERROR: pattern Rect(w) has 1 fields, but variant Rect has 2"},
+		{shape + "try { match (Empty) { Rect(w) => w } } catch (e) { e.position }", "2:23"},
+		{"@ x = 1\nmatch (1) { x(y) => y }", "ERROR: x is not an enum variant"},
+		{shape + "Circle(1) + 1", "ERROR: type mismatch: Shape + INTEGER"},
+		{"enum INTEGER { A }\nA + A", "ERROR: unknown operator: INTEGER + INTEGER"},
+		{"enum ERROR { Boom }\n@ xs = [Boom]\nlen(xs)", "1"},
+		{"enum ERROR { Boom }\nBoom", "Boom"},
+	})
+}
+
+func TestStructs(t *testing.T) {
+	const point = "struct Point { x, y }\n"
+	expectInspect(t, []struct{ input, expect string }{
+		{point + "Point { y: 2, x: 1 }", "Point { x: 1, y: 2 }"},
+		{point + "Point", "struct Point { x, y }"},
+		{point + "@ p = Point { x: 3, y: 4 }\np.x * p.y", "12"},
+		{point + "@ p = Point { x: 3, y: 4 }\np.x = 10\np.y += 1\np", "Point { x: 10, y: 5 }"},
+		{point + "@ line = [Point { x: 0, y: 0 }, Point { x: 1, y: 1 }]\nline[1].y", "1"},
+		{`@ h = {"name": "ada"}
+h.age = 36
+[h.name, h.age, h.missing]`, "[ada, 36, null]"},
+		{"enum Shape { Circle(r), Empty }\n[Shape.Circle(1), Shape.Empty == Empty]", "[Circle(1), true]"},
+
+		{point + "@ p = Point { x: 1, y: 2 }\np.z", "ERROR: Point has no field or method z"},
+		{point + "@ p = Point { x: 1, y: 2 }\np.z = 3", "ERROR: struct Point has no field z"},
+		{"fn f() { Point { x: 1, z: 2 } }\nstruct Point { x, y }\nf()", "ERROR: struct Point has no field z"},
+		{"fn f() { Point { x: 1 } }\nstruct Point { x, y }\nf()", "ERROR: missing field y in Point literal"},
+		{"@ Point = 1\nPoint { x: 1 }", "ERROR: Point is not a struct"},
+		{"Point { x: 1 }", "ERROR: identifier not found: Point"},
+		{"1.x", "ERROR: member access not supported: INTEGER"},
+		{"@ xs = [1]\nxs.x = 1", "ERROR: member assignment not supported: ARRAY"},
+		{"enum Shape { Circle(r) }\nShape.Square", "ERROR: enum Shape has no variant or method Square"},
+		{point + "Point { x: 1, y: 2 } + 1", "ERROR: type mismatch: Point + INTEGER"},
+		{"struct INTEGER { v }\n@ a = INTEGER { v: 1 }\na + a", "ERROR: unknown operator: INTEGER + INTEGER"},
+		{"struct ARRAY { v }\n@ a = ARRAY { v: 1 }\na[0]", "ERROR: index operator not supported: ARRAY"},
+	})
+}
+
+func TestMethods(t *testing.T) {
+	const point = `struct Point { x, y }
+impl Point {
+	fn sum(self) { self.x + self.y }
+	fn distance(self, other) { (self.x - other.x) * (self.x - other.x) + (self.y - other.y) * (self.y - other.y) }
+	fn origin() { Point { x: 0, y: 0 } }
+	fn move(self, dx, dy = 0) { self.x += dx; self.y += dy; self }
+}
+`
+	expectInspect(t, []struct{ input, expect string }{
+		{point + "Point { x: 3, y: 4 }.distance(Point.origin())", "25"},
+		{point + "@ p = Point { x: 1, y: 2 }\np.move(dy: 1, dx: 2)\np", "Point { x: 3, y: 3 }"},
+		{point + "@ p = Point { x: 1, y: 2 }\n@ f = p.sum\np.x = 10\nf()", "12"},
+		{point + "@ p = Point { x: 1, y: 2 }\np.sum", "method Point.sum"},
+		{point + "Point.sum(Point { x: 1, y: 2 })", "3"},
+		{point + "Point.sum", "fn Point.sum(self) { ((self.x) + (self.y)) }"},
+		{point + "@ ps = [Point { x: 1, y: 1 }, Point { x: 2, y: 2 }]\n@ mut total = 0\nfor (p in ps) { @ f = p.sum; total += f() }\ntotal", "6"},
+		{point + "impl Point { fn sum(self) { 0 } }\nPoint.origin().sum()", "0"},
+		{"struct Counter { n }\nimpl Counter { fn next(self) { self.n += 1 } }\n@ c = Counter { n: 0 }\n@ next = c.next\nnext(); next()\nc.n", "2"},
+		{"@ h = {\"f\": fn(x) { x * 2 }}\nh.f(21)", "42"},
+		{`enum Shape { Circle(r), Square(s) }
+impl Shape {
+	fn area(self) { match (self) { Circle(r) => 3 * r * r, Square(s) => s * s } }
+}
+[Circle(1).area(), Square(2).area()]`, "[3, 4]"},
+		{"fn f() { struct P { x }\nimpl P { fn get(self) { self.x } }\nP { x: 5 } }\nf().get()", "5"},
+
+		{point + "Point.origin().norm()", "ERROR: Point has no field or method norm"},
+		{point + "Point { x: 1, y: 2 }.origin()", "ERROR: method origin of Point takes no self, so call it as Point.origin"},
+		{point + "Point.nothing", "ERROR: struct Point has no method nothing"},
+		{point + "Point.origin().distance()", "ERROR: missing argument other in call to Point.distance"},
+		{"struct P { x }\nimpl P { fn x(self) { 1 } }", "ERROR: method x of P has the same name as a field"},
+		{"enum E { A }\nimpl E { fn A() { 1 } }", "ERROR: method A of E has the same name as a variant"},
+		{"@ n = 1\nimpl n { fn f() { 1 } }", "ERROR: cannot impl n: not a struct or enum"},
+		{"impl Nope { fn f() { 1 } }", "ERROR: identifier not found: Nope"},
+	})
+}
+
+func TestOperatorOverloading(t *testing.T) {
+	const vec = `struct Vec { x, y }
+impl Vec {
+	fn __add__(self, other) { Vec { x: self.x + other.x, y: self.y + other.y } }
+	fn __mul__(self, k) { Vec { x: self.x * k, y: self.y * k } }
+	fn __neg__(self) { Vec { x: -self.x, y: -self.y } }
+	fn __eq__(self, other) { if (other.x == self.x) { other.y == self.y } el { false } }
+	fn __lt__(self, other) { self.x * self.x + self.y * self.y < other.x * other.x + other.y * other.y }
+	fn __hash__(self) { self.x }
+	fn __index__(self, i) { if (i == 0) { self.x } el { self.y } }
+	fn __setindex__(self, i, v) { if (i == 0) { self.x = v } el { self.y = v } }
+}
+fn v(x, y) { Vec { x: x, y: y } }
+`
+	expectInspect(t, []struct{ input, expect string }{
+		{vec + "v(1, 2) + v(3, 4)", "Vec { x: 4, y: 6 }"},
+		{vec + "v(1, 2) * 3", "Vec { x: 3, y: 6 }"},
+		{vec + "-v(1, 2)", "Vec { x: -1, y: -2 }"},
+		{vec + "@ mut a = v(1, 1)\na += v(1, 0)\na", "Vec { x: 2, y: 1 }"},
+		{vec + "[v(1, 2) == v(1, 2), v(1, 2) != v(1, 2), v(1, 2) != v(2, 1)]", "[true, false, true]"},
+		{vec + "[v(1, 0) < v(0, 2), v(0, 2) > v(1, 0), v(1, 0) > v(0, 2)]", "[true, true, false]"},
+		{vec + "@ a = v(5, 6)\n[a[0], a[1]]", "[5, 6]"},
+		{vec + "@ a = v(5, 6)\na[1] = 7\na[0] += 1\na", "Vec { x: 6, y: 7 }"},
+		{vec + "sort([v(3, 0), v(1, 1), v(0, 2), v(1, 0)])", "[Vec { x: 1, y: 0 }, Vec { x: 1, y: 1 }, Vec { x: 0, y: 2 }, Vec { x: 3, y: 0 }]"},
+		{vec + "sort([v(0, 1), v(1, 0)])", "[Vec { x: 0, y: 1 }, Vec { x: 1, y: 0 }]"},
+		{vec + "@ h = {v(1, 2): \"a\", v(1, 3): \"b\"}\nh[v(1, 2)] = \"c\"\n[h[v(1, 2)], h[v(1, 3)], h[v(1, 4)], len(h)]", "[c, b, null, 2]"},
+		{vec + "@ h = {v(1, 2): 1}\n{...h, v(1, 2): 2}", "{Vec { x: 1, y: 2 }: 2}"},
+		{vec + "match (v(1, 2)) { [] => 0, _ => 1 }", "1"},
+		{"enum Money { Cents(n) }\nimpl Money { fn __add__(self, o) { match ([self, o]) { [Cents(a), Cents(b)] => Cents(a + b) } } }\nCents(1) + Cents(2)", "Cents(3)"},
+		{"sort([3, 1, 2])", "[1, 2, 3]"},
+
+		{vec + "v(1, 2) - v(1, 2)", "ERROR: unknown operator: Vec - Vec"},
+		{vec + "v(1, 2) + 1", "ERROR: member access not supported: INTEGER"},
+		{"struct P { x }\n@ p = P { x: 1 }\n{p: 1}", "ERROR: unusable as hash key: P"},
+		{"struct P { x }\nimpl P { fn __hash__(self) { \"x\" } }\n{P { x: 1 }: 1}", "ERROR: __hash__ of P must return INTEGER, got STRING"},
+		{"sort([1, \"a\"])", "ERROR: type mismatch: STRING < INTEGER"},
+		{"sort(1)", "ERROR: argument to `sort` must be ARRAY, got INTEGER"},
+	})
+}
+
+func TestExceptions(t *testing.T) {
+	expectInspect(t, []struct{ input, expect string }{
+		{`try { throw "boom" } catch (e) { "caught " + e }`, "caught boom"},
+		{`try { 1 } catch (e) { 2 }`, "1"},
+		{`try { throw {"code": 7} } catch (e) { e.code }`, "7"},
+		{`try { 1 + "a" } catch (e) { [e.kind, e.message, e.position] }`, "[TypeError, type mismatch: INTEGER + STRING, 1:9]"},
+		{"try {\n  [1][5] = 0\n} catch (e) { [e.kind, e.position] }", "[IndexError, 2:10]"},
+		{`try { len(1) } catch (e) { [e.kind, e.message] }`, "[TypeError, argument to `len` not supported, got INTEGER]"},
+		{`try { nope } catch (e) { e.kind }`, "NameError"},
+		{`try { 1 / 0 } catch (e) { e }`, "ArithmeticError: division by zero"},
+		{"fn f() {\n  g()\n}\nfn g() { 1 + true }\ntry { f() } catch (e) { e.position }", "4:12"},
+		{`try { throw "x" } catch { "ignored" }`, "ignored"},
+		{`@ mut log = []
+fn f() { try { ret 1 } finally { log = push(log, "finally") } }
+[f(), log]`, "[1, [finally]]"},
+		{`@ mut n = 0
+while (true) { try { break } finally { n += 1 } }
+n`, "1"},
+		{`@ mut n = 0
+try { try { throw "inner" } finally { n += 1 } } catch (e) { [e, n] }`, "[inner, 1]"},
+		{`try { try { 1 + true } catch (e) { throw e } } catch (e) { [e.kind, e.position] }`, "[TypeError, 1:15]"},
+		{`try { try { throw "a" } catch (e) { throw "b" } } catch (e) { e }`, "b"},
+		{`fn f() { try { ret 1 } finally { ret 2 } }
+f()`, "2"},
+		{`try { throw "a" } catch (e) { 1 } finally { 2 }`, "1"},
+		{`@ x = 1
+try { throw "a" } catch (x) { x }`, "a"},
+		{`try { throw "a" } catch (e) { @ y = 1 }
+y`, "ERROR: identifier not found: y"},
+
+		{`throw "boom"`, "ERROR: boom"},
+		{`throw [1]`, "ERROR: uncaught [1]"},
+		{`try { throw "a" } finally { 1 }`, "ERROR: a"},
+		{`try { 1 } catch (e) { 2 } finally { throw "f" }`, "ERROR: f"},
+		{`try { 1 + true } catch (e) { e.line }`, "ERROR: exception has no field line"},
+	})
+}
+
+func TestDefer(t *testing.T) {
+	expectInspect(t, []struct{ input, expect string }{
+		{`@ mut log = []
+fn note(x) { log = push(log, x) }
+fn f() {
+	defer note(1)
+	defer note(2)
+	note(0)
+}
+f()
+log`, "[0, 2, 1]"},
+		{`@ mut log = []
+fn note(x) { log = push(log, x) }
+fn f() { defer note("deferred"); ret "returned" }
+[f(), log]`, "[returned, [deferred]]"},
+		{`@ mut log = []
+fn note(x) { log = push(log, x) }
+fn f(x) { defer note(x); 1 + true }
+[try { f(1) } catch (e) { e.kind }, log]`, "[TypeError, [1]]"},
+		{`@ mut log = []
+fn note(x) { log = push(log, x) }
+fn f() { @ mut x = 1; defer note(x); x = 2 }
+f()
+log`, "[1]"},
+		{`@ mut log = []
+fn f() {
+	for (i in 0..3) { defer fn(i) { log = push(log, i) }(i) }
+}
+f()
+log`, "[2, 1, 0]"},
+		{`@ mut seen = 0
+fn f() {
+	defer fn() { seen = recover() }()
+	throw "boom"
+}
+[f(), seen]`, "[null, boom]"},
+		{`@ mut seen = 0
+fn f() {
+	defer fn() { seen = recover() }()
+	[1][0] = 1 / 0
+}
+[f(), seen.kind, seen.message]`, "[null, ArithmeticError, division by zero]"},
+		{`fn f() { defer recover(); throw "x" }
+f()`, "ERROR: x"},
+		{`fn f() { defer fn() { recover() }(); throw "x" }
+f()`, "null"},
+		{`@ mut seen = 0
+fn f() { defer fn() { seen = recover() }(); 1 }
+[f(), seen]`, "[1, null]"},
+		{`fn helper() { recover() }
+fn f() { defer fn() { helper() }(); throw "not recovered" }
+f()`, "ERROR: not recovered"},
+		{`fn f() { defer fn() { throw "from defer" }(); throw "original" }
+f()`, "ERROR: from defer"},
+		{`fn f() { defer fn() { @ e = recover(); throw e + "!" }(); throw "again" }
+try { f() } catch (e) { e }`, "again!"},
+		{`struct Box { items }
+impl Box { fn add(self, x) { self.items = push(self.items, x) } }
+@ b = Box { items: [] }
+fn f() { defer b.add(1); b.add(0) }
+f()
+b.items`, "[0, 1]"},
+		{"recover()", "null"},
+		{"fn f() { recover() }\nf()", "null"},
+		{"defer f()", "ERROR: defer outside a function"},
+		{"fn f() { defer nope() }\nf()", "ERROR: identifier not found: nope"},
+	})
+}
+
+func TestCallStack(t *testing.T) {
+	const program = `fn g(x) {
+  x + true
+}
+fn f() {
+  @ h = fn() { g(1) }
+  h()
+}
+`
+	expectInspect(t, []struct{ input, expect string }{
+		{program + "try { f() } catch (e) { e.frames }", "[{function: g, position: 5:17, elided: 2, entry: 8:8}]"},
+		{"try { 1 + true } catch (e) { e.frames }", "[]"},
+		{"fn f() { throw \"x\" }\nfn g() { try { f() } catch (e) { throw e } }\ntry { g() } catch (e) { e }", "x"},
+		{"fn f() { [][0] = 1 }\nfn g() { try { f() } catch (e) { throw e } }\ntry { g() } catch (e) { e.frames }", "[{function: f, position: 2:17}, {function: g, position: 3:8}]"},
+		{"struct P { x }\nimpl P { fn __add__(self, o) { self.x + o } }\nfn f() { P { x: 1 } + true }\ntry { f() } catch (e) { e.frames }", "[{function: P.__add__, position: 3:21}, {function: f, position: 4:8}]"},
+		{"fn f() { sort([[1], [2]]) }\ntry { f() } catch (e) { [e.position, e.frames] }", "[1:14, [{function: f, position: 2:8}]]"},
+		{"fn f() { defer fn() { 1 + true }() }\ntry { f() } catch (e) { e.frames }", "[{function: <anonymous>, position: 1:10}, {function: f, position: 2:8}]"},
+		{"fn g() { 1 + true }\nfn f() { g() }\ntry { f() } catch (e) { e.frames }", "[{function: g, position: 2:11, elided: 1, entry: 3:8}]"},
+		{"fn g(x) { x + true }\nfn f() { ret g(1) }\nfn h() { f(); 0 }\ntry { h() } catch (e) { e.frames }", "[{function: g, position: 2:15, elided: 1, entry: 3:11}, {function: h, position: 4:8}]"},
+	})
+
+	expectTrace(t, []struct{ input, expect string }{
+		{program + "f()", `error: type mismatch: INTEGER + BOOLEAN [TypeError]
+
+g(...)
+	2:5
+...2 calls elided by tail calls
+	5:17
+<main>
+	8:2
+`},
+		{"fn g() { 1 + true }\nfn f() { g() }\nfn h() { f(); 0 }\nh()", `error: type mismatch: INTEGER + BOOLEAN [TypeError]
+
+g(...)
+	1:12
+...1 call elided by tail calls
+	2:11
+h(...)
+	3:11
+<main>
+	4:2
+`},
+	})
+}
+
+func TestResults(t *testing.T) {
+	const parse = `fn parse(s) {
+	match (s) { "one" => ok(1), "two" => ok(2), _ => err("bad number " + s) }
+}
+`
+	expectInspect(t, []struct{ input, expect string }{
+		{"[ok(1), err(\"boom\")]", "[ok(1), err(boom)]"},
+		{parse + `fn sum(a, b) { ok(parse(a)? + parse(b)?) }
+[sum("one", "two"), sum("one", "six"), sum("ten", "six")]`, "[ok(3), err(bad number six), err(bad number ten)]"},
+		{parse + `@ mut calls = 0
+fn f() { calls += 1; parse("x")?; calls += 1 }
+[f(), calls]`, "[err(bad number x), 1]"},
+		{parse + `fn first(xs) { for (x in xs) { ret ok(parse(x)?) } }
+first(["two", "x"])`, "ok(2)"},
+		{parse + `@ mut calls = 0
+fn f(s, n = parse(s)?) { calls += 1; ok(n) }
+[f("one"), f("x"), f("x", n: 5), calls]`, "[ok(1), err(bad number x), ok(5), 2]"},
+		{parse + `fn f(s, n = parse(s)?) { ok(n) }
+fn g(s) { f(s) }
+g("x")`, "err(bad number x)"},
+		{parse + `fn f() { match (parse("x")) { ok(n) => n, err(e) => "failed: " + e } }
+f()`, "failed: bad number x"},
+		{"match (ok([1, 2])) { ok([a, b]) => a + b, err(_) => 0 }", "3"},
+		{"[ok(1) == ok(1), ok(1) == err(1), err(\"a\") != err(\"b\"), ok(1) == 1]", "[true, false, true, false]"},
+		{"[is_ok(ok(1)), is_err(ok(1)), is_err(err(1))]", "[true, false, true]"},
+		{"[unwrap(ok(1)), unwrap_or(err(1), 0), unwrap_or(ok(5), 0)]", "[1, 0, 5]"},
+		{"[map_ok(ok(2), fn(x) { x * 10 }), map_ok(err(2), fn(x) { x * 10 })]", "[ok(20), err(2)]"},
+		{"[map_err(err(\"x\"), fn(e) { e + \"!\" }), map_err(ok(1), fn(e) { e + \"!\" })]", "[err(x!), ok(1)]"},
+		{"@ ok = fn(x) { x }\nmatch (1) { ok(x) => x }", "ERROR: ok is not an enum variant"},
+
+		{"fn f() { 1? }\nf()", "ERROR: ? needs a RESULT, got INTEGER"},
+		{"unwrap(err(\"boom\"))", "ERROR: unwrap of err(boom)"},
+		{"try { unwrap(err(1)) } catch (e) { e.kind }", "ResultError"},
+		{"is_ok(1)", "ERROR: argument to `is_ok` must be RESULT, got INTEGER"},
+		{"map_ok(ok(1), fn(x) { x + true })", "ERROR: type mismatch: INTEGER + BOOLEAN"},
+		{"match (ok(1)) { ok(a, b) => a }", "ERROR: pattern ok(a, b) has 2 fields, but ok has 1"},
+		{"try { match (ok(1)) { ok(a, b) => a } } catch (e) { e.position }", "1:23"},
+	})
+}
+
+func TestNullSafety(t *testing.T) {
+	const data = `@ user = {"name": "ada", "tags": ["x"], "greet": fn(s) { "hi " + s }}
+@ none = user.missing
+`
+	expectInspect(t, []struct{ input, expect string }{
+		{data + "[user?.name, none?.name, none?.name?.first]", "[ada, null, null]"},
+		{data + "[user?.tags?[0], user.missing?[0], none?[1 / 0]]", "[x, null, null]"},
+		{data + "[user.greet?.(\"bob\"), none?.(1 / 0), user.missing?.(1)]", "[hi bob, null, null]"},
+		{data + "[user?.name ?? \"anon\", none?.name ?? \"anon\", none ?? none ?? 3]", "[ada, anon, 3]"},
+		{data + "[false ?? 1, 0 ?? 1, user.name ?? 1 / 0]", "[false, 0, ada]"},
+		{data + "@ mut calls = 0\nfn f() { calls += 1 }\n[none ?? f(), user ?? f(), calls]", "[1, {name: ada, tags: [x], greet: fn(s) { (\"hi \" + s) }}, 1]"},
+		{`struct P { x }
+impl P { fn get(self) { self.x } }
+@ p = P { x: 1 }
+@ q = {}.q
+[p?.get(), q?.get(), q?.x]`, "[1, null, null]"},
+		{"{}.a ?? 1 + 2", "3"},
+		{`fn get(v) { if (v > 0) { ok({"x": v}) } el { err("no") } }
+fn arr(v) { if (v > 0) { ok([v + 6]) } el { err("no") } }
+fn x(v) { ok(get(v)?.x) }
+fn first(v) { ok(arr(v)?[0]) }
+fn call(v) { @ f = if (v > 0) { ok(fn() { v }) } el { err("no") }; ok(f?.()) }
+[x(1), x(0), first(1), first(0), call(2), call(0)]`, "[ok(1), err(no), ok(7), err(no), ok(2), err(no)]"},
+
+		{data + "none.name", "ERROR: member access not supported: NULL"},
+		{data + "none?.name.first", "ERROR: member access not supported: NULL"},
+		{data + "user?.greet(1)", "ERROR: type mismatch: STRING + INTEGER"},
+		{"1 + {}.a ?? 2", "ERROR: type mismatch: INTEGER + NULL"},
+	})
+}
+
+func TestPipes(t *testing.T) {
+	const lib = `fn map(xs, f) { @ mut out = []; for (x in xs) { out = push(out, f(x)) }; out }
+fn filter(xs, f) { @ mut out = []; for (x in xs) { if (f(x)) { out = push(out, x) } }; out }
+fn sum(xs, from = 0) { @ mut total = from; for (x in xs) { total += x }; total }
+`
+	expectInspect(t, []struct{ input, expect string }{
+		{lib + "[1, 2, 3, 4] |> map(fn(x) { x * x }) |> filter(fn(x) { x > 4 }) |> sum()", "25"},
+		{lib + "[1, 2] |> sum(from: 10)", "13"},
+		{lib + "@ xs = [1, 2, 3] |> len\nxs", "3"},
+		{"@ twice = fn(x) { x * 2 }\n1 + 2 |> twice |> fn(x) { x + 1 }", "7"},
+		{"{}.f ?? fn(x) { x } |> fn(f) { f(5) }", "5"},
+		{"ok(2) |> map_ok(fn(x) { x + 1 })", "ok(3)"},
+		{"[1, 2, 3, 4] |> map(x => x * x) |> filter(x => x > 4) |> sum()", "25"},
+		{"[map([], x => x), filter([1, 2], x => false), sum([])]", "[[], [], 0]"},
+		{`sum(["a", "b", "c"])`, "abc"},
+
+		{"@ x = 1\n2 |> x", "ERROR: not a function: INTEGER"},
+		{"[] |> first(1)", "ERROR: wrong number of arguments. got=2, want=1"},
+		{"map(1, x => x)", "ERROR: argument to `map` must be ARRAY, got INTEGER"},
+		{"filter([1], x => x.y)", "ERROR: member access not supported: INTEGER"},
+		{`sum([1, "a"])`, "ERROR: type mismatch: INTEGER + STRING"},
+	})
+}
+
+func TestLambdasAndPartialApplication(t *testing.T) {
+	const lib = `fn map(xs, f) { @ mut out = []; for (x in xs) { out = push(out, f(x)) }; out }
+fn add(a, b) { a + b }
+`
+	expectInspect(t, []struct{ input, expect string }{
+		{lib + "map([1, 2, 3], x => x * 2)", "[2, 4, 6]"},
+		{"((a, b) => a - b)(5, 3)", "2"},
+		{"(() => 7)()", "7"},
+		{"((a, b = 10) => a + b)(1)", "11"},
+		{"@ adder = n => x => x + n\n@ add2 = adder(2)\n[add2(1), adder(5)(1)]", "[3, 6]"},
+		{"@ mut n = 1\n@ f = () => n\nn = 2\nf()", "2"},
+		{"fn f(xs) { map(xs, x => if (x > 1) { ret 100 } el { x }) }\n" + lib + "f([1, 2])", "[1, 100]"},
+
+		{lib + "@ inc = add(1, _)\n[inc(2), inc(10)]", "[3, 11]"},
+		{lib + "map([1, 2], add(_, 10))", "[11, 12]"},
+		{lib + "add(_, _)(3, 4)", "7"},
+		{"fn sub(a, b, c = 0) { a - b - c }\n[sub(_, 1)(10), sub(10, _, c: 5)(1), sub(_, 1)(10, 2)]", "[9, 4, 7]"},
+		{lib + "@ mut fs = []\nfor (i in 0..3) { fs = push(fs, add(i, _)) }\nmap(fs, f => f(10))", "[10, 11, 12]"},
+		{lib + "@ mut fs = []\nfor (i in 0..3) { fs = push(fs, () => i) }\nmap(fs, f => f())", "[0, 1, 2]"},
+		{lib + "@ mut fs = []\nfor (i in 0..3) { fs = push(fs, fn() { i }) }\nmap(fs, f => f())", "[0, 1, 2]"},
+		{lib + "@ mut fs = []\nfor (i in 0..3) { @ j = i * 10; fs = push(fs, () => j) }\nmap(fs, f => f())", "[0, 10, 20]"},
+		{lib + "@ mut fs = []\n@ mut i = 0\nwhile (i < 3) { @ j = i; fs = push(fs, x => x + j); i += 1 }\nmap(fs, f => f(10))", "[10, 11, 12]"},
+		{lib + "@ mut fs = []\n@ mut i = 0\nwhile (i < 3) { @ j = i; fs = push(fs, fn() { j }); i += 1 }\nmap(fs, f => f())", "[0, 1, 2]"},
+		{lib + "@ mut calls = 0\nfn next() { calls += 1; calls }\n@ f = add(next(), _)\n[f(0), f(0), calls]", "[1, 1, 1]"},
+		{"struct P { x }\nimpl P { fn plus(self, n) { self.x + n } }\n@ f = P { x: 1 }.plus(_)\nf(2)", "3"},
+		{"@ f = push(_, 3)\n[f([1]), f([])]", "[[1, 3], [3]]"},
+		{"fn add(a, b) { a + b }\nadd(1, _)", "fn add(a, b) { (a + b) }(1, _)"},
+		{lib + "[1, 2] |> map(x => x + 1) |> map(add(10, _))", "[12, 13]"},
+
+		{lib + "add(_, 1)()", "ERROR: not enough arguments for the placeholders of a partial application: want 1, got 0"},
+		{lib + "add(1, _)(1, 2)", "ERROR: too many arguments in call to add: want at most 2, got 3"},
+		{"_", "ERROR: identifier not found: _"},
+	})
+}
+
+func TestTailCalls(t *testing.T) {
+	// Without tail calls, even a hundred thousand nested calls need more
+	// stack than this.
+	defer debug.SetMaxStack(debug.SetMaxStack(64 << 20))
+
+	expectInspect(t, []struct{ input, expect string }{
+		{"fn count(n) { if (n == 0) { \"done\" } el { count(n - 1) } }\ncount(1000000)", "done"},
+		{"fn sum(n, acc) {\n  if (n == 0) { ret acc }\n  ret sum(n - 1, acc + n)\n}\nsum(1000000, 0)", "500000500000"},
+		{"fn loop(n) { while (true) { if (n == 0) { ret n }; ret loop(n - 1) } }\nloop(100000)", "0"},
+		{`fn even(n) { match (n) { 0 => true, _ => odd(n - 1) } }
+fn odd(n) { match (n) { 0 => false, _ => { even(n - 1) } } }
+[even(100000), odd(100001)]`, "[true, true]"},
+		{"@ down = (n, acc) => if (n == 0) { acc } el { down(n - 1, acc + 1) }\ndown(100000, 0)", "100000"},
+		{"struct C { }\nimpl C { fn run(self, n) { if (n == 0) { n } el { self.run(n - 1) } } }\nC { }.run(100000)", "0"},
+		{"fn count(n) { if (n == 0) { 0 } el { n |> fn(m) { m - 1 } |> count } }\ncount(100000)", "0"},
+
+		{"fn f(n) { if (n == 0) { 0 } el { 1 + f(n - 1) } }\nf(100)", "100"},
+		{"@ mut log = []\nfn f(n) { defer fn() { log = push(log, n) }(); if (n == 0) { 0 } el { f(n - 1) } }\n[f(2), log]", "[0, [0, 1, 2]]"},
+		{"fn f(n) { try { if (n == 0) { throw \"x\" } el { f(n - 1) } } catch (e) { n } }\nf(3)", "0"},
+		{"fn f(n) { if (n == 0) { len(1) } el { f(n - 1) } }\ntry { f(3) } catch (e) { [e.message, e.position, e.frames] }",
+			"[argument to `len` not supported, got INTEGER, 1:28, [{function: f, position: 1:40, elided: 3, entry: 2:8}]]"},
+		{"fn f(a) { a }\nfn g() { f(1, 2) }\ng()", "ERROR: too many arguments in call to f: want at most 1, got 2"},
+	})
+}
+
+func TestProgramsStartWithEmptyCallStack(t *testing.T) {
+	tests := []struct{ input, expect string }{
+		{"try { 1 + true } catch (e) { e.frames }", "[]"},
+		{"fn f() { 1 + true }\ntry { f() } catch (e) { e.frames }", "[{function: f, position: 2:8}]"},
+		{"defer puts(1)", "ERROR: defer outside a function"},
+	}
+
+	for _, test := range tests {
+		// An environment whose evaluation was abandoned in the middle of a
+		// call, leaving its frame behind.
+		env := object.NewEnvironment()
+		stale := callsOf(env)
+		stale.frames = append(stale.frames, &frame{function: "stale", site: token.Position{Line: 9, Column: 9}})
+		stale.site = token.Position{Line: 9, Column: 9}
+
+		if got := testEvalIn(t, test.input, env).Inspect(); got != test.expect {
+			t.Fatalf("Wanted: %s, Got: %s", test.expect, got)
+		}
+		if n := len(callsOf(env).frames); n != 0 {
+			t.Fatalf("Wanted an empty call stack, Got: %d frames", n)
+		}
+	}
+}
+
+func TestEvaluationsHaveTheirOwnCallStacks(t *testing.T) {
+	const input = `fn f(n) {
+	if (n == 0) {
+		try { 1 + true } catch (e) { len(e.frames) }
+	} el {
+		defer fn() { recover() }()
+		f(n - 1)
+	}
+}
+f(50)`
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				if got := testEval(t, input).Inspect(); got != "51" {
+					t.Errorf("Wanted: 51, Got: %s", got)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
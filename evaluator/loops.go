@@ -0,0 +1,133 @@
+package evaluator
+
+import (
+	"github.com/0xedb/intlang/ast"
+	"github.com/0xedb/intlang/object"
+)
+
+// evalWhileStatement runs the body of ws while its condition holds. Like
+// the body of a for loop, each pass through the body has a scope of its own.
+func evalWhileStatement(ws *ast.WhileStatement, env *object.Environment) object.Object {
+	for {
+		condition := Eval(ws.Condition, env)
+		if isError(condition) {
+			return condition
+		}
+		if !isTruthy(condition) {
+			return NULL
+		}
+
+		result := Eval(ws.Body, object.NewEnclosedEnvironment(env))
+		if stop, result := loopControl(ws.Label, result); stop {
+			return result
+		}
+	}
+}
+
+func evalForStatement(fs *ast.ForStatement, env *object.Environment) object.Object {
+	iterable := Eval(fs.Iterable, env)
+	if isError(iterable) {
+		return iterable
+	}
+
+	_, isHash := iterable.(*object.Hash)
+
+	var result object.Object
+	stopped := false
+
+	// Each pass through the body has a scope of its own, in which the loop
+	// variables are bound, so closures made in different passes see
+	// different bindings.
+	iterated := iterate(iterable, func(key, value object.Object) bool {
+		scope := object.NewEnclosedEnvironment(env)
+		if fs.Key != nil {
+			scope.Set(fs.Key.Value, key)
+		} else if isHash {
+			value = key
+		}
+		scope.Set(fs.Value.Value, value)
+
+		stopped, result = loopControl(fs.Label, Eval(fs.Body, scope))
+		return !stopped
+	})
+	if !iterated {
+		return newError(object.TypeError, "cannot iterate over %s", object.TypeName(iterable))
+	}
+
+	if stopped {
+		return result
+	}
+
+	return NULL
+}
+
+// loopControl decides what a loop labelled label does with the result of
+// one pass through its body. It reports whether the loop must stop and, if
+// so, what the loop evaluates to: NULL when it was broken out of, or the
+// value that has to keep unwinding (a return, an error, or a break or
+// continue aimed at an outer loop).
+func loopControl(label *ast.Identifier, result object.Object) (bool, object.Object) {
+	switch result := result.(type) {
+	case *object.Break:
+		if targets(label, result.Label) {
+			return true, NULL
+		}
+		return true, result
+	case *object.Continue:
+		if targets(label, result.Label) {
+			return false, nil
+		}
+		return true, result
+	case *object.ReturnValue, *object.Error:
+		return true, result
+	}
+
+	return false, nil
+}
+
+// targets reports whether a break or continue naming target applies to the
+// loop labelled label.
+func targets(label *ast.Identifier, target string) bool {
+	return target == "" || label != nil && label.Value == target
+}
+
+// iterate calls yield with successive key and value pairs of obj until it
+// returns false: index and element for arrays, rune index and character for
+// strings, key and value for hashes, and index and integer for ranges. With a
+// single loop variable, the value is used, except for hashes where the key
+// is. iterate reports false if obj cannot be iterated over.
+func iterate(obj object.Object, yield func(key, value object.Object) bool) bool {
+	switch obj := obj.(type) {
+	case *object.Array:
+		for i, element := range obj.Elements {
+			if !yield(&object.Integer{Value: int64(i)}, element) {
+				break
+			}
+		}
+	case *object.String:
+		i := int64(0)
+		for _, ch := range obj.Value {
+			if !yield(&object.Integer{Value: i}, &object.String{Value: string(ch)}) {
+				break
+			}
+			i++
+		}
+	case *object.Hash:
+		for _, hashKey := range obj.Keys {
+			pair := obj.Pairs[hashKey]
+			if !yield(pair.Key, pair.Value) {
+				break
+			}
+		}
+	case *object.Range:
+		for i := int64(0); i < obj.Len(); i++ {
+			if !yield(&object.Integer{Value: i}, &object.Integer{Value: obj.Start + i}) {
+				break
+			}
+		}
+	default:
+		return false
+	}
+
+	return true
+}
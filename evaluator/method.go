@@ -0,0 +1,108 @@
+package evaluator
+
+import (
+	"github.com/0xedb/intlang/ast"
+	"github.com/0xedb/intlang/object"
+)
+
+// evalImplStatement adds the methods of an impl block to the struct or enum
+// it names. A later impl block may replace a method, but not shadow a field
+// or variant.
+func evalImplStatement(node *ast.ImplStatement, env *object.Environment) object.Object {
+	def, ok := env.Get(node.Type.Value)
+	if !ok {
+		return newError(object.NameError, "identifier not found: "+node.Type.Value)
+	}
+
+	var methods object.Methods
+	switch def := def.(type) {
+	case *object.StructType:
+		methods = def.Methods
+		for _, m := range node.Methods {
+			if def.FieldIndex(m.Name) >= 0 {
+				return newError(object.NameError, "method %s of %s has the same name as a field", m.Name, def.Name)
+			}
+		}
+	case *object.Enum:
+		methods = def.Methods
+		for _, m := range node.Methods {
+			if variant(def, m.Name) != nil {
+				return newError(object.NameError, "method %s of %s has the same name as a variant", m.Name, def.Name)
+			}
+		}
+	default:
+		return newError(object.TypeError, "cannot impl %s: not a struct or enum", node.Type.Value)
+	}
+
+	for _, m := range node.Methods {
+		fn := newFunction(m, env)
+		fn.Name = node.Type.Value + "." + m.Name
+		methods[m.Name] = fn
+	}
+
+	return nil
+}
+
+// evalCallee evaluates the function of a call. A call of a method on a value,
+// `p.distance(q)`, looks the method up directly and returns p as the
+// receiver to pass as self, without binding the method first. A call of an
+// optional member of null, `p?.distance(q)`, is skipped. The function of an
+// optional call, `f?.(x)`, is an optional operand.
+func evalCallee(node ast.Expression, optional bool, env *object.Environment) (fn, receiver object.Object, skip bool) {
+	m, ok := node.(*ast.MemberExpression)
+	if !ok {
+		fn, _ := evalOperand(node, optional, env)
+		return fn, nil, false
+	}
+
+	obj, done := evalOperand(m.Object, m.Optional, env)
+	if done {
+		return obj, nil, obj == NULL
+	}
+	if method := methodOf(obj, m.Member.Value); method != nil {
+		return method, obj, false
+	}
+
+	return member(obj, m.Member.Value), nil, false
+}
+
+// methodOf returns the method named name that can be called on obj, or nil
+// if there is none.
+func methodOf(obj object.Object, name string) *object.Function {
+	var methods object.Methods
+	switch obj := obj.(type) {
+	case *object.Struct:
+		methods = obj.Def.Methods
+	case *object.EnumValue:
+		methods = obj.Variant.Enum.Methods
+	default:
+		return nil
+	}
+
+	if method, ok := methods[name]; ok && takesSelf(method) {
+		return method
+	}
+
+	return nil
+}
+
+// takesSelf reports whether method is called on a value rather than on its
+// type: whether its first parameter is named self.
+func takesSelf(method *object.Function) bool {
+	if len(method.Parameters) == 0 || method.Parameters[0].Rest {
+		return false
+	}
+
+	id, ok := method.Parameters[0].Target.(*ast.Identifier)
+	return ok && id.Value == "self"
+}
+
+// missingMember reports a failed lookup of name on a value of a type with
+// the given methods.
+func missingMember(typ string, methods object.Methods, name string) object.Object {
+	if _, ok := methods[name]; ok {
+		return newError(object.MemberError, "method %s of %s takes no self, so call it as %s.%s", name, typ, typ, name)
+	}
+
+	return newError(object.MemberError, "%s has no field or method %s", typ, name)
+}
@@ -0,0 +1,138 @@
+package evaluator
+
+import (
+	"sort"
+
+	"github.com/0xedb/intlang/object"
+)
+
+// operatorMethods names the methods through which a struct or enum
+// overloads an operator. Each takes self and the other operand.
+var operatorMethods = map[string]string{
+	"+":  "__add__",
+	"-":  "__sub__",
+	"*":  "__mul__",
+	"/":  "__div__",
+	"%":  "__mod__",
+	"==": "__eq__",
+	"!=": "__ne__",
+	"<":  "__lt__",
+	">":  "__gt__",
+}
+
+// reflectedOperators gives, for each comparison, the one that asks the same
+// question with the operands swapped.
+var reflectedOperators = map[string]string{
+	"==": "==",
+	"<":  ">",
+	">":  "<",
+}
+
+// evalOperatorMethod applies the method by which left overloads operator. A
+// comparison the left operand does not overload is tried on the right one,
+// and != falls back to negating ==. It reports false if no method applies.
+func evalOperatorMethod(operator string, left, right object.Object, env *object.Environment) (object.Object, bool) {
+	if method := methodOf(left, operatorMethods[operator]); method != nil {
+		result := applyFunction(method, []object.Object{left, right}, nil, env)
+		if _, ok := reflectedOperators[operator]; ok || operator == "!=" {
+			return truth(result, true), true
+		}
+		return result, true
+	}
+
+	if reflected, ok := reflectedOperators[operator]; ok {
+		if method := methodOf(right, operatorMethods[reflected]); method != nil {
+			return truth(applyFunction(method, []object.Object{right, left}, nil, env), true), true
+		}
+	}
+
+	if operator == "!=" {
+		if result, ok := evalOperatorMethod("==", left, right, env); ok {
+			return truth(result, false), true
+		}
+	}
+
+	return nil, false
+}
+
+// truth converts the result of a comparison method to a boolean, negated
+// unless want is true.
+func truth(result object.Object, want bool) object.Object {
+	if isError(result) {
+		return result
+	}
+
+	return nativeBoolToBooleanObject(isTruthy(result) == want)
+}
+
+// hashKey returns where key is stored in hash, or would be stored if hash
+// does not contain it. A struct or enum value is usable as a key if its type
+// has a __hash__ method returning an integer; keys whose hashes collide are
+// told apart with ==, so a user-defined __eq__ decides which keys are the
+// same. Hashes never lose keys, so the probe for a free slot ends at the
+// first one that is empty.
+func hashKey(hash *object.Hash, key object.Object, env *object.Environment) (object.HashKey, object.Object) {
+	if key, ok := key.(object.Hashable); ok {
+		return key.HashKey(), nil
+	}
+
+	method := methodOf(key, "__hash__")
+	if method == nil {
+		return object.HashKey{}, newError(object.TypeError, "unusable as hash key: %s", object.TypeName(key))
+	}
+
+	result := applyFunction(method, []object.Object{key}, nil, env)
+	if isError(result) {
+		return object.HashKey{}, result
+	}
+	h, ok := result.(*object.Integer)
+	if !ok {
+		return object.HashKey{}, newError(object.TypeError, "__hash__ of %s must return INTEGER, got %s", object.TypeName(key), object.TypeName(result))
+	}
+
+	// The type name keeps apart the keys of different structs or enums,
+	// which share a tag.
+	slot := object.HashKey{Type: key.Type() + object.ObjectType(" "+object.TypeName(key)), Value: uint64(h.Value)}
+	for {
+		pair, ok := hash.Pairs[slot]
+		if !ok {
+			return slot, nil
+		}
+
+		equal := evalInfixExpression("==", pair.Key, key, env)
+		if isError(equal) {
+			return slot, equal
+		}
+		if isTruthy(equal) {
+			return slot, nil
+		}
+		slot.Value++
+	}
+}
+
+// sortArray returns the elements of arr in ascending order according to <,
+// keeping elements that are not less than each other in their original
+// order. It stops at the first comparison that fails.
+func sortArray(arr *object.Array, env *object.Environment) object.Object {
+	elements := make([]object.Object, len(arr.Elements))
+	copy(elements, arr.Elements)
+
+	var err object.Object
+	sort.SliceStable(elements, func(i, j int) bool {
+		if err != nil {
+			return false
+		}
+
+		less := evalInfixExpression("<", elements[i], elements[j], env)
+		if isError(less) {
+			err = less
+			return false
+		}
+		return isTruthy(less)
+	})
+	if err != nil {
+		return err
+	}
+
+	return &object.Array{Elements: elements}
+}
@@ -0,0 +1,43 @@
+package evaluator
+
+import "github.com/0xedb/intlang/object"
+
+// partial returns the function made by a call with placeholders among its
+// arguments, `add(1, _)`, or nil if it has none. The function and the other
+// arguments are those evaluated when the call was made.
+func partial(fn object.Object, args []object.Object, named []object.NamedArgument) object.Object {
+	for _, arg := range args {
+		if arg == nil {
+			return &object.Partial{Function: fn, Args: args, Named: named}
+		}
+	}
+
+	return nil
+}
+
+// applyPartial calls a partial application with args filling its
+// placeholders in order; any arguments left over follow the ones it was made
+// with, and named arguments are added to its own.
+func applyPartial(p *object.Partial, args []object.Object, named []object.NamedArgument, env *object.Environment) object.Object {
+	holes := 0
+	for _, arg := range p.Args {
+		if arg == nil {
+			holes++
+		}
+	}
+	if len(args) < holes {
+		return newError(object.ArgumentError, "not enough arguments for the placeholders of a partial application: want %d, got %d",
+			holes, len(args))
+	}
+
+	filled := make([]object.Object, 0, len(p.Args)+len(args)-holes)
+	for _, arg := range p.Args {
+		if arg == nil {
+			arg, args = args[0], args[1:]
+		}
+		filled = append(filled, arg)
+	}
+	filled = append(filled, args...)
+
+	return applyFunction(p.Function, filled, append(p.Named[:len(p.Named):len(p.Named)], named...), env)
+}
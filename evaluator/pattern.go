@@ -0,0 +1,186 @@
+package evaluator
+
+import (
+	"github.com/0xedb/intlang/ast"
+	"github.com/0xedb/intlang/object"
+)
+
+// binder binds a name found in a pattern to a value.
+type binder func(name *ast.Identifier, value object.Object)
+
+// mismatch is the error matchPattern returns when a value does not have the
+// shape of a pattern, as opposed to an error raised while matching it.
+type mismatch struct {
+	*object.Error
+}
+
+func newMismatch(format string, a ...interface{}) *mismatch {
+	return &mismatch{newError(object.MatchError, format, a...)}
+}
+
+// bindPattern binds the names in pattern to the parts of value they stand
+// for, returning an error if value does not have the shape of pattern.
+func bindPattern(pattern ast.Pattern, value object.Object, env *object.Environment, bind binder) object.Object {
+	err := matchPattern(pattern, value, env, bind)
+	if m, ok := err.(*mismatch); ok {
+		return m.Error
+	}
+
+	return err
+}
+
+// matchPattern calls bind for each name in pattern with the part of value it
+// stands for, evaluating defaults in env as it goes so that they can refer
+// to the names bound before them. It returns a *mismatch if value does not
+// have the shape of pattern, in which case some names may have been bound
+// already.
+func matchPattern(pattern ast.Pattern, value object.Object, env *object.Environment, bind binder) object.Object {
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		bind(pattern, value)
+	case *ast.WildcardPattern:
+	case *ast.LiteralPattern:
+		return matchLiteral(pattern, value, env)
+	case *ast.OrPattern:
+		for _, alt := range pattern.Alternatives {
+			err := matchPattern(alt, value, env, bind)
+			if _, ok := err.(*mismatch); !ok {
+				return err
+			}
+		}
+		return newMismatch("%s does not match %s", value.Inspect(), pattern)
+	case *ast.VariantPattern:
+		return matchVariantPattern(pattern, value, env, bind)
+	case *ast.ArrayPattern:
+		return matchArrayPattern(pattern, value, env, bind)
+	case *ast.HashPattern:
+		return matchHashPattern(pattern, value, env, bind)
+	}
+
+	return nil
+}
+
+func matchLiteral(pattern *ast.LiteralPattern, value object.Object, env *object.Environment) object.Object {
+	literal := Eval(pattern.Value, env)
+	if isError(literal) {
+		return literal
+	}
+
+	if literal.Type() != value.Type() || evalInfixExpression("==", literal, value, env) != TRUE {
+		return newMismatch("%s does not match %s", value.Inspect(), pattern)
+	}
+
+	return nil
+}
+
+func matchArrayPattern(pattern *ast.ArrayPattern, value object.Object, env *object.Environment, bind binder) object.Object {
+	array, ok := value.(*object.Array)
+	if !ok {
+		return newMismatch("cannot destructure %s with %s", object.TypeName(value), pattern)
+	}
+
+	elements := array.Elements
+	if pattern.Rest == nil && len(elements) > len(pattern.Elements) {
+		return newMismatch("cannot destructure array of length %d with %s: too many elements", len(elements), pattern)
+	}
+
+	for i, element := range pattern.Elements {
+		var value object.Object
+		if i < len(elements) {
+			value = elements[i]
+		} else if element.Default == nil {
+			return newMismatch("cannot destructure array of length %d with %s: missing element %d", len(elements), pattern, i)
+		}
+
+		if err := matchElement(element, value, env, bind); err != nil {
+			return err
+		}
+	}
+
+	if pattern.Rest != nil {
+		rest := []object.Object{}
+		if len(elements) > len(pattern.Elements) {
+			rest = append(rest, elements[len(pattern.Elements):]...)
+		}
+		bind(pattern.Rest, &object.Array{Elements: rest})
+	}
+
+	return nil
+}
+
+func matchHashPattern(pattern *ast.HashPattern, value object.Object, env *object.Environment, bind binder) object.Object {
+	hash, ok := value.(*object.Hash)
+	if !ok {
+		return newMismatch("cannot destructure %s with %s", object.TypeName(value), pattern)
+	}
+
+	for _, entry := range pattern.Entries {
+		var value object.Object
+		key := &object.String{Value: entry.Key.Value}
+		if pair, ok := hash.Pairs[key.HashKey()]; ok {
+			value = pair.Value
+		} else if entry.Value.Default == nil {
+			return newMismatch("cannot destructure hash with %s: missing key %q", pattern, entry.Key.Value)
+		}
+
+		if err := matchElement(entry.Value, value, env, bind); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// matchElement matches element against value, or binds it to its default if
+// value is nil.
+func matchElement(element *ast.PatternElement, value object.Object, env *object.Environment, bind binder) object.Object {
+	if value == nil {
+		value = unwrapReturnValue(Eval(element.Default, env))
+		if isError(value) {
+			return value
+		}
+	}
+
+	return matchPattern(element.Pattern, value, env, bind)
+}
+
+// evalMatchExpression evaluates the body of the first arm of node whose
+// pattern matches and whose guard holds. Each arm gets its own scope for the
+// names its pattern binds.
+func evalMatchExpression(node *ast.MatchExpression, env *object.Environment) object.Object {
+	value := Eval(node.Value, env)
+	if isError(value) {
+		return value
+	}
+
+	for _, arm := range node.Arms {
+		armEnv := object.NewEnclosedEnvironment(env)
+		err := matchPattern(arm.Pattern, value, armEnv, func(name *ast.Identifier, value object.Object) {
+			// An alternative of an or-pattern binds again the names bound by
+			// the alternatives before it that failed to match.
+			if b, ok := armEnv.Declare(name.Value, value, false, name.Token.Pos); !ok {
+				b.Value = value
+			}
+		})
+		if _, ok := err.(*mismatch); ok {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if arm.Guard != nil {
+			guard := Eval(arm.Guard, armEnv)
+			if isError(guard) {
+				return guard
+			}
+			if !isTruthy(guard) {
+				continue
+			}
+		}
+
+		return Eval(arm.Body, armEnv)
+	}
+
+	return newError(object.MatchError, "no arm of match matches %s", value.Inspect())
+}
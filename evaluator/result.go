@@ -0,0 +1,109 @@
+package evaluator
+
+import (
+	"github.com/0xedb/intlang/ast"
+	"github.com/0xedb/intlang/object"
+)
+
+// evalPropagateExpression unwraps an ok result, or returns an err result from
+// the enclosing function.
+func evalPropagateExpression(node *ast.PropagateExpression, env *object.Environment) object.Object {
+	val := Eval(node.Value, env)
+	if isError(val) {
+		return val
+	}
+
+	return propagate(val)
+}
+
+// propagate unwraps an ok result, or returns an err result from the
+// enclosing function.
+func propagate(val object.Object) object.Object {
+	result, ok := val.(*object.Result)
+	if !ok {
+		return newError(object.TypeError, "? needs a RESULT, got %s", object.TypeName(val))
+	}
+	if !result.Ok {
+		return &object.ReturnValue{Value: result}
+	}
+
+	return result.Value
+}
+
+// evalOperand evaluates the operand of a member, index or call, and reports
+// whether the access ends there: on an error, or on null if the access is
+// optional. The result operand of an optional access is propagated first, so
+// `get(k)?.x` and `get(k)?[0]` mean `get(k)?` followed by the access, as they
+// would if ?. and ?[ were not tokens of their own.
+func evalOperand(node ast.Expression, optional bool, env *object.Environment) (object.Object, bool) {
+	val := Eval(node, env)
+	if isError(val) || !optional {
+		return val, isError(val)
+	}
+	if _, ok := val.(*object.Result); ok {
+		val = propagate(val)
+		if isError(val) {
+			return val, true
+		}
+	}
+
+	return val, val == NULL
+}
+
+// resultsEqual reports whether two results are both ok or both err, with
+// equal values.
+func resultsEqual(a, b *object.Result, env *object.Environment) object.Object {
+	if a.Ok != b.Ok {
+		return FALSE
+	}
+
+	return evalInfixExpression("==", a.Value, b.Value, env)
+}
+
+// matchResultPattern matches value against `ok(p)` or `err(p)`, which are
+// patterns wherever ok and err name the builtins that make results.
+func matchResultPattern(pattern *ast.VariantPattern, value object.Object, env *object.Environment, bind binder) object.Object {
+	if len(pattern.Fields) != 1 {
+		return locate(newError(object.MatchError, "pattern %s has %d fields, but %s has 1",
+			pattern, len(pattern.Fields), pattern.Name.Value), pattern.Token.Pos, env)
+	}
+
+	result, ok := value.(*object.Result)
+	if !ok || result.Ok != (pattern.Name.Value == "ok") {
+		return newMismatch("%s does not match %s", value.Inspect(), pattern)
+	}
+
+	return matchPattern(pattern.Fields[0], result.Value, env, bind)
+}
+
+// result returns the argument of a result builtin that must be a result.
+func result(name string, arg object.Object) (*object.Result, object.Object) {
+	r, ok := arg.(*object.Result)
+	if !ok {
+		return nil, newError(object.TypeError, "argument to `%s` must be RESULT, got %s", name, object.TypeName(arg))
+	}
+
+	return r, nil
+}
+
+// mapResult applies fn to the value of r if it is ok (or err, for
+// map_err), wrapping what it returns in a result of the same kind.
+func mapResult(name string, args []object.Object, ok bool, env *object.Environment) object.Object {
+	if len(args) != 2 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=2", len(args))
+	}
+	r, err := result(name, args[0])
+	if err != nil {
+		return err
+	}
+	if r.Ok != ok {
+		return r
+	}
+
+	val := applyFunction(args[1], []object.Object{r.Value}, nil, env)
+	if isError(val) {
+		return val
+	}
+
+	return &object.Result{Ok: ok, Value: val}
+}
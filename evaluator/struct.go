@@ -0,0 +1,146 @@
+package evaluator
+
+import (
+	"github.com/0xedb/intlang/ast"
+	"github.com/0xedb/intlang/object"
+)
+
+// evalStructStatement declares the struct as an immutable binding.
+func evalStructStatement(node *ast.StructStatement, env *object.Environment) object.Object {
+	fields := make([]string, len(node.Fields))
+	for i, f := range node.Fields {
+		fields[i] = f.Value
+	}
+
+	st := &object.StructType{Name: node.Name.Value, Fields: fields, Methods: object.Methods{}}
+	return declare(env, node.Name, st, false)
+}
+
+func evalStructLiteral(node *ast.StructLiteral, env *object.Environment) object.Object {
+	def, ok := env.Get(node.Name.Value)
+	if !ok {
+		return newError(object.NameError, "identifier not found: "+node.Name.Value)
+	}
+	st, ok := def.(*object.StructType)
+	if !ok {
+		return newError(object.TypeError, "%s is not a struct", node.Name.Value)
+	}
+
+	values := make([]object.Object, len(st.Fields))
+	for _, field := range node.Fields {
+		i := st.FieldIndex(field.Name.Value)
+		if i < 0 {
+			return newError(object.MemberError, "struct %s has no field %s", st.Name, field.Name.Value)
+		}
+
+		val := Eval(field.Value, env)
+		if isError(val) {
+			return val
+		}
+		values[i] = val
+	}
+
+	for i, val := range values {
+		if val == nil {
+			return newError(object.ArgumentError, "missing field %s in %s literal", st.Fields[i], st.Name)
+		}
+	}
+
+	return &object.Struct{Def: st, Values: values}
+}
+
+func evalMemberExpression(node *ast.MemberExpression, env *object.Environment) object.Object {
+	obj, done := evalOperand(node.Object, node.Optional, env)
+	if done {
+		return obj
+	}
+
+	return member(obj, node.Member.Value)
+}
+
+// member returns a field or bound method of a struct or enum value, the
+// value at a string key of a hash (null if there is none), a variant of an
+// enum, or a method of a struct or enum, which takes its receiver as an
+// ordinary first argument.
+func member(obj object.Object, name string) object.Object {
+	if method := methodOf(obj, name); method != nil {
+		return &object.BoundMethod{Receiver: obj, Method: method}
+	}
+
+	switch obj := obj.(type) {
+	case *object.Struct:
+		i := obj.Def.FieldIndex(name)
+		if i < 0 {
+			return missingMember(obj.Def.Name, obj.Def.Methods, name)
+		}
+		return obj.Values[i]
+	case *object.EnumValue:
+		enum := obj.Variant.Enum
+		return missingMember(enum.Name, enum.Methods, name)
+	case *object.Exception:
+		return exceptionField(obj, name)
+	case *object.StructType:
+		if method, ok := obj.Methods[name]; ok {
+			return method
+		}
+		return newError(object.MemberError, "struct %s has no method %s", obj.Name, name)
+	case *object.Hash:
+		key := &object.String{Value: name}
+		if pair, ok := obj.Pairs[key.HashKey()]; ok {
+			return pair.Value
+		}
+		return NULL
+	case *object.Enum:
+		if v := variant(obj, name); v != nil {
+			if v.Fields == nil {
+				return &object.EnumValue{Variant: v}
+			}
+			return v
+		}
+		if method, ok := obj.Methods[name]; ok {
+			return method
+		}
+		return newError(object.MemberError, "enum %s has no variant or method %s", obj.Name, name)
+	}
+
+	return newError(object.TypeError, "member access not supported: %s", object.TypeName(obj))
+}
+
+func evalAssignMember(node *ast.AssignExpression, target *ast.MemberExpression, env *object.Environment) object.Object {
+	obj := Eval(target.Object, env)
+	if isError(obj) {
+		return obj
+	}
+	name := target.Member.Value
+
+	switch obj := obj.(type) {
+	case *object.Struct:
+		i := obj.Def.FieldIndex(name)
+		if i < 0 {
+			return newError(object.MemberError, "struct %s has no field %s", obj.Def.Name, name)
+		}
+
+		val := evalAssignedValue(node, obj.Values[i], env)
+		if isError(val) {
+			return val
+		}
+		obj.Values[i] = val
+		return val
+	case *object.Hash:
+		key := &object.String{Value: name}
+
+		var current object.Object = NULL
+		if pair, ok := obj.Pairs[key.HashKey()]; ok {
+			current = pair.Value
+		}
+
+		val := evalAssignedValue(node, current, env)
+		if isError(val) {
+			return val
+		}
+		obj.Set(key, val)
+		return val
+	}
+
+	return newError(object.TypeError, "member assignment not supported: %s", object.TypeName(obj))
+}
@@ -0,0 +1,117 @@
+package evaluator
+
+import (
+	"github.com/0xedb/intlang/ast"
+	"github.com/0xedb/intlang/object"
+)
+
+// evalThrowStatement raises the value of the throw statement. Throwing a
+// caught exception rethrows the error it was caught from, so it keeps its
+// kind and position.
+func evalThrowStatement(node *ast.ThrowStatement, env *object.Environment) object.Object {
+	val := Eval(node.Value, env)
+	if isError(val) {
+		return val
+	}
+
+	if ex, ok := val.(*object.Exception); ok {
+		return ex.Err
+	}
+
+	message := val.Inspect()
+	if _, ok := val.(*object.String); !ok {
+		message = "uncaught " + message
+	}
+
+	return &object.Error{Message: message, Kind: object.ThrownError, Pos: node.Token.Pos, Value: val, Frames: callsOf(env).stack()}
+}
+
+// evalTryExpression evaluates the try block, then the catch block if the try
+// block raised an error, and then the finally block. Unless the finally block
+// itself ends in a return, error, break or continue, the try expression ends
+// the way the try or catch block did.
+func evalTryExpression(node *ast.TryExpression, env *object.Environment) object.Object {
+	result := Eval(node.Block, env)
+
+	if err, ok := result.(*object.Error); ok && node.Catch != nil {
+		result = evalCatch(node, err, env)
+	}
+
+	if node.Finally != nil {
+		if done := Eval(node.Finally, env); unwinds(done) {
+			return done
+		}
+	}
+
+	if result == nil {
+		return NULL
+	}
+
+	return result
+}
+
+// evalCatch runs the catch block in a scope of its own, with the caught
+// error bound immutably to the catch parameter: the thrown value itself, or
+// an exception for an error raised by the evaluator.
+func evalCatch(node *ast.TryExpression, err *object.Error, env *object.Environment) object.Object {
+	catchEnv := object.NewEnclosedEnvironment(env)
+
+	if node.Param != nil {
+		catchEnv.Declare(node.Param.Value, caught(err), false, node.Param.Token.Pos)
+	}
+
+	return Eval(node.Catch, catchEnv)
+}
+
+// caught returns the value a program sees when it catches or recovers from
+// err: the thrown value itself, or an exception for an error raised by the
+// evaluator.
+func caught(err *object.Error) object.Object {
+	if err.Value != nil {
+		return err.Value
+	}
+
+	return &object.Exception{Err: err}
+}
+
+// unwinds reports whether obj is a value that ends the evaluation of the
+// blocks enclosing it.
+func unwinds(obj object.Object) bool {
+	switch obj.(type) {
+	case *object.ReturnValue, *object.Error, *object.Break, *object.Continue:
+		return true
+	}
+
+	return false
+}
+
+// exceptionField returns the field of a caught exception named name. Its
+// frames are the calls that were being evaluated, innermost first, each with
+// the function called and the position it was called at. A call made by a
+// tail call also has the number of calls it replaced, as elided, and where
+// the first of them was made, as entry.
+func exceptionField(ex *object.Exception, name string) object.Object {
+	switch name {
+	case "message":
+		return &object.String{Value: ex.Err.Message}
+	case "kind":
+		return &object.String{Value: string(ex.Err.Kind)}
+	case "position":
+		return &object.String{Value: ex.Err.Pos.String()}
+	case "frames":
+		frames := make([]object.Object, len(ex.Err.Frames))
+		for i, f := range ex.Err.Frames {
+			frame := object.NewHash()
+			frame.Set(&object.String{Value: "function"}, &object.String{Value: f.Function})
+			frame.Set(&object.String{Value: "position"}, &object.String{Value: f.Site.String()})
+			if f.Elided > 0 {
+				frame.Set(&object.String{Value: "elided"}, &object.Integer{Value: int64(f.Elided)})
+				frame.Set(&object.String{Value: "entry"}, &object.String{Value: f.Entry.String()})
+			}
+			frames[i] = frame
+		}
+		return &object.Array{Elements: frames}
+	}
+
+	return newError(object.MemberError, "exception has no field %s", name)
+}
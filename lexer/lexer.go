@@ -1,118 +1,328 @@
 package lexer
 
 import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
 	"github.com/0xedb/intlang/token"
 )
 
+const (
+	bom = '\uFEFF'
+
+	// ReadSize is how many bytes a Lexer created by NewReader requests from
+	// its source at a time.
+	ReadSize = 4096
+)
+
+// Lexer turns UTF-8 source text into tokens. It advances one rune at a time;
+// pos and offset are byte offsets into input, while line and col count runes
+// so that positions match what an editor shows.
+//
+// A Lexer reading from an io.Reader only keeps the unconsumed part of the
+// current token plus one read's worth of input, or as much again as the token
+// for a long one, in memory: input is a window that is trimmed at every token
+// boundary and extended by fill.
 type Lexer struct {
 	input       string
 	pos, offset int
-	ch          byte
+	ch          rune
+	line, col   int
+	errors      []string
+
+	// insertSemi is set after a token that may end a statement, so that a
+	// newline or the end of input following it produces a SEMICOLON.
+	insertSemi bool
+
+	src       io.Reader
+	buf       []byte
+	streaming bool
+	err       error
 }
 
 func New(input string) *Lexer {
 	l := new(Lexer)
 	l.input = input
 
-	l.readChar()
+	l.init()
 	return l
 }
 
+// NewReader returns a Lexer that reads its input from r as it goes, ReadSize
+// bytes at a time. Read errors other than io.EOF end the input and are
+// reported by Next.
+func NewReader(r io.Reader) *Lexer {
+	l := new(Lexer)
+	l.src = r
+	l.buf = make([]byte, ReadSize)
+	l.streaming = true
+
+	l.init()
+	return l
+}
+
+func (l *Lexer) init() {
+	l.line = 1
+	l.errors = []string{}
+
+	l.readChar()
+	if l.ch == bom {
+		l.readChar()
+		l.col = 1
+	}
+}
+
+// fill reads from the source until input holds at least n bytes or the
+// source is exhausted. Appending leaves existing offsets into input valid.
+//
+// Appending copies input, so fill reads at least as much as input already
+// holds before it appends. A token longer than a read, such as a long string
+// literal, then doubles input at every append and is read in linear time.
+func (l *Lexer) fill(n int) {
+	if l.src == nil || len(l.input) >= n {
+		return
+	}
+
+	chunk := l.buf[:0]
+	for l.src != nil && (len(l.input)+len(chunk) < n || len(chunk) < len(l.input)) {
+		if len(chunk) == cap(chunk) {
+			chunk = append(chunk, 0)[:len(chunk)]
+		}
+		read, err := l.src.Read(chunk[len(chunk):cap(chunk)])
+		chunk = chunk[:len(chunk)+read]
+
+		if err != nil {
+			if err != io.EOF {
+				l.err = err
+			}
+			l.src = nil
+		}
+	}
+
+	l.input += string(chunk)
+	l.buf = chunk
+}
+
+// trim drops the consumed part of a streaming lexer's window.
+func (l *Lexer) trim() {
+	if !l.streaming || l.pos == 0 || l.pos > len(l.input) {
+		return
+	}
+
+	l.input = l.input[l.pos:]
+	l.offset -= l.pos
+	l.pos = 0
+}
+
 func (l *Lexer) readChar() {
+	if l.ch == '\n' {
+		l.line++
+		l.col = 0
+	}
+
+	l.pos = l.offset
+	l.col++
+
+	if l.src != nil && l.offset+utf8.UTFMax > len(l.input) {
+		l.fill(l.offset + utf8.UTFMax)
+	}
+
 	if l.offset >= len(l.input) {
 		l.ch = 0
-	} else {
-		l.ch = l.input[l.offset]
+		l.offset++
+		return
 	}
 
-	l.pos = l.offset
-	l.offset++
+	ch, width := rune(l.input[l.offset]), 1
+	if ch >= utf8.RuneSelf {
+		ch, width = utf8.DecodeRuneInString(l.input[l.offset:])
+		if ch == utf8.RuneError && width == 1 {
+			l.error(l.position(), "invalid UTF-8 encoding")
+		}
+	}
+
+	l.ch = ch
+	l.offset += width
+}
+
+// invalid reports whether the current character is an undecodable byte,
+// which readChar has already reported.
+func (l *Lexer) invalid() bool {
+	return l.ch == utf8.RuneError && l.offset-l.pos == 1
+}
+
+// Errors returns the problems found while lexing, each prefixed with the
+// line:column at which it occurred.
+func (l *Lexer) Errors() []string {
+	return l.errors
+}
+
+func (l *Lexer) error(pos token.Position, format string, args ...interface{}) {
+	msg := fmt.Sprintf("%s: %s", pos, fmt.Sprintf(format, args...))
+	l.errors = append(l.errors, msg)
+}
+
+func (l *Lexer) position() token.Position {
+	return token.Position{Line: l.line, Column: l.col}
+}
+
+// single maps each ASCII character that always forms a token on its own to
+// that token's type, so the common case is a single table lookup.
+var single = [utf8.RuneSelf]token.Token{
+	'<': token.LST,
+	'>': token.GRT,
+	',': token.COMMA,
+	':': token.COLON,
+	';': token.SEMICOLON,
+	')': token.RPAREN,
+	'(': token.LPAREN,
+	'}': token.RCURL,
+	'{': token.LCURL,
+	']': token.RBRAC,
+	'[': token.LBRAC,
+	'@': token.AT,
+}
+
+// Next returns the next token in the input. At the end of the input it
+// returns an EOF token, together with the error that ended it the first time
+// if reading from the source failed.
+func (l *Lexer) Next() (token.TokenObj, error) {
+	tok := l.NextToken()
+
+	if err := l.err; tok.Token == token.EOF && err != nil {
+		l.err = nil
+		return tok, err
+	}
+
+	return tok, nil
 }
 
+// NextToken returns the next token in the input. Literals are slices of the
+// input rather than copies, so lexing well-formed source does not allocate.
+// A streaming lexer copies them instead so that they do not pin its window.
 func (l *Lexer) NextToken() token.TokenObj {
+	tok := l.nextToken()
+	l.insertSemi = endsStatement(tok.Token)
+
+	if l.streaming {
+		tok.Literal = string([]byte(tok.Literal))
+	}
+
+	return tok
+}
+
+func (l *Lexer) nextToken() token.TokenObj {
 	var tok token.TokenObj
 
 	l.eatWhitespace()
+	l.trim()
+	start := l.position()
 
-	switch string(l.ch) {
-	case token.PLUS:
-		tok = makeToken(token.PLUS, l.ch)
-	case token.MINUS:
-		tok = makeToken(token.MINUS, l.ch)
-	case token.MULT:
-		tok = makeToken(token.MULT, l.ch)
-	case token.DIV:
-		tok = makeToken(token.DIV, l.ch)
-	case token.NOT:
-		if string(l.peekChar()) == token.ASSIGN {
-			l.readChar()
-			tok.Token = token.NEQL
-			tok.Literal = token.NEQL
-		} else {
-			tok = makeToken(token.NOT, l.ch)
+	switch l.ch {
+	case '\n':
+		tok = token.TokenObj{Token: token.SEMICOLON, Literal: "\n"}
+	case '!':
+		tok = l.either('=', token.NEQL, token.NOT)
+	case '=':
+		tok = l.either('=', token.EQL, token.ASSIGN)
+		if tok.Token == token.ASSIGN {
+			tok = l.either('>', token.ARROW, token.ASSIGN)
 		}
-	case token.ASSIGN:
-		if string(l.peekChar()) == token.EQL {
+	case '+':
+		tok = l.either('=', token.PLUS_ASSIGN, token.PLUS)
+	case '-':
+		tok = l.either('=', token.MINUS_ASSIGN, token.MINUS)
+	case '*':
+		tok = l.either('=', token.MULT_ASSIGN, token.MULT)
+	case '/':
+		tok = l.either('=', token.DIV_ASSIGN, token.DIV)
+	case '%':
+		tok = l.either('=', token.MOD_ASSIGN, token.MOD)
+	case '.':
+		tok = l.either('.', token.DOTDOT, token.DOT)
+		if tok.Token == token.DOTDOT && l.peekChar() == '.' {
 			l.readChar()
-			tok.Token = token.EQL
-			tok.Literal = token.EQL
-		} else {
-			tok = makeToken(token.ASSIGN, l.ch)
-		}
-	case token.LST:
-		tok = makeToken(token.LST, l.ch)
-	case token.GRT:
-		tok = makeToken(token.GRT, l.ch)
-	case token.COMMA:
-		tok = makeToken(token.COMMA, l.ch)
-	case token.COLON:
-		tok = makeToken(token.COLON, l.ch)
-	case token.SEMICOLON:
-		tok = makeToken(token.SEMICOLON, l.ch)
-	case token.RPAREN:
-		tok = makeToken(token.RPAREN, l.ch)
-	case token.LPAREN:
-		tok = makeToken(token.LPAREN, l.ch)
-	case token.RCURL:
-		tok = makeToken(token.RCURL, l.ch)
-	case token.LCURL:
-		tok = makeToken(token.LCURL, l.ch)
-	case token.RBRAC:
-		tok = makeToken(token.RBRAC, l.ch)
-	case token.LBRAC:
-		tok = makeToken(token.LBRAC, l.ch)
-	case token.STRING:
-		tok = makeToken(token.STRING, l.ch)
-	case token.AT:
-		tok = makeToken(token.AT, l.ch)
-	case string(byte(0)):
+			tok = token.TokenObj{Token: token.ELLIPSIS, Literal: l.input[l.offset-3 : l.offset]}
+		}
+	case '|':
+		tok = l.either('>', token.PIPE_GT, token.PIPE)
+	case '?':
+		switch l.peekChar() {
+		case '.':
+			tok = l.either('.', token.OPTIONAL_DOT, token.QUESTION)
+		case '[':
+			tok = l.either('[', token.OPTIONAL_LBRAC, token.QUESTION)
+		default:
+			tok = l.either('?', token.NULLISH, token.QUESTION)
+		}
+	case '"':
+		literal, ok := l.readString(start)
+		tok.Token = token.STRING
+		if !ok {
+			tok.Token = token.ILLEGAL
+		}
+		tok.Literal = literal
+	case 0:
+		if l.insertSemi {
+			tok = token.TokenObj{Token: token.SEMICOLON, Literal: "\n", Pos: start}
+			return tok
+		}
 		tok.Literal = ""
 		tok.Token = token.EOF
 
 	default:
-		if token.IsNumber(l.ch) {
+		if l.ch < utf8.RuneSelf && single[l.ch] != "" {
+			tok = l.makeToken(single[l.ch])
+		} else if token.IsNumber(l.ch) {
+			literal, ok := l.readNumber(start)
 			tok.Token = token.INT
-			tok.Literal = l.readNumber()
+			if !ok {
+				tok.Token = token.ILLEGAL
+			}
+			tok.Literal = literal
+			tok.Pos = start
 			return tok
 		} else if token.IsLetter(l.ch) {
 			tok.Literal = l.readIdentifier()
 			tok.Token = token.LookupIdentifier(tok.Literal)
+			tok.Pos = start
 			return tok
 		} else {
-			tok = makeToken(token.ILLEGAL, l.ch)
+			if !l.invalid() {
+				l.error(start, "unexpected character %q", l.ch)
+			}
+			tok = l.makeToken(token.ILLEGAL)
 		}
 
 	}
 
 	l.readChar()
+	tok.Pos = start
 	return tok
 }
 
-func makeToken(tok token.Token, ch byte) token.TokenObj {
-	return token.TokenObj{Token: tok, Literal: string(ch)}
+// makeToken returns a token of type tok spelled by the current character.
+func (l *Lexer) makeToken(tok token.Token) token.TokenObj {
+	return token.TokenObj{Token: tok, Literal: l.input[l.pos:l.offset]}
+}
+
+// either returns a two-character token of type two if the next character is
+// next, consuming it, and a one-character token of type one otherwise.
+func (l *Lexer) either(next byte, two, one token.Token) token.TokenObj {
+	if l.peekChar() != next {
+		return l.makeToken(one)
+	}
+
+	cur := l.pos
+	l.readChar()
+	return token.TokenObj{Token: two, Literal: l.input[cur:l.offset]}
 }
 
 func (l *Lexer) peekChar() byte {
+	l.fill(l.offset + 1)
+
 	if l.offset >= len(l.input) {
 		return 0
 	}
@@ -120,28 +330,251 @@ func (l *Lexer) peekChar() byte {
 	return l.input[l.offset]
 }
 
-func (l *Lexer) readNumber() string {
+// readNumber consumes an integer literal in any of the forms accepted by
+// strconv.ParseInt with base 0: decimal, 0x hexadecimal, 0o (or legacy 0)
+// octal and 0b binary, each optionally using '_' between digits. The literal
+// is returned exactly as spelled; ok is false if it is malformed, in which
+// case the problem has already been reported.
+func (l *Lexer) readNumber(start token.Position) (literal string, ok bool) {
 	cur := l.pos
+	base, prefix := 10, byte(0)
+
+	if l.ch == '0' {
+		switch lower(l.peekChar()) {
+		case 'x':
+			base, prefix = 16, 'x'
+		case 'o':
+			base, prefix = 8, 'o'
+		case 'b':
+			base, prefix = 2, 'b'
+		default:
+			base, prefix = 8, '0'
+		}
+
+		if prefix != '0' {
+			l.readChar()
+			l.readChar()
+		}
+	}
 
-	for token.IsNumber(l.ch) {
+	for isDigit(l.ch, base) || l.ch == '_' {
 		l.readChar()
 	}
 
-	return l.input[cur:l.pos]
+	literal = l.input[cur:l.pos]
+	column := func(i int) token.Position {
+		return token.Position{Line: start.Line, Column: start.Column + i}
+	}
+
+	explicit := prefix != 0 && prefix != '0'
+	if explicit && len(strings.Trim(literal[2:], "_")) == 0 {
+		l.error(start, "%s literal has no digits", baseName(base))
+		return literal, false
+	}
+
+	for i := 0; i < len(literal); i++ {
+		if explicit && i == 1 {
+			continue
+		}
+		if d := literal[i]; d != '_' && digitValue(d) >= base {
+			l.error(column(i), "invalid digit %q in %s literal", d, baseName(base))
+			return literal, false
+		}
+	}
+
+	if i := invalidSeparator(literal); i >= 0 {
+		l.error(column(i), "'_' must separate successive digits")
+		return literal, false
+	}
+
+	return literal, true
 }
 
+// readIdentifier follows Go's rules: a letter or '_' followed by any number
+// of letters, '_' and digits, where letters and digits may be any Unicode
+// code point in those categories.
 func (l *Lexer) readIdentifier() string {
 	cur := l.pos
 
-	for token.IsLetter(l.ch) {
+	for token.IsLetter(l.ch) || token.IsDigit(l.ch) {
 		l.readChar()
 	}
 
 	return l.input[cur:l.pos]
 }
 
+// readPlainString consumes the common case of a string literal without
+// escapes or invalid UTF-8 and returns it as a slice of the input. If it
+// meets anything else first, it returns what it has read so far with done
+// set to false, leaving the lexer so that the next readChar lands on the
+// character it stopped at.
+func (l *Lexer) readPlainString() (prefix string, done bool) {
+	cur, i, runes := l.offset, l.offset, 0
+
+	for l.fill(i + utf8.UTFMax); i < len(l.input); l.fill(i + utf8.UTFMax) {
+		ch, width := rune(l.input[i]), 1
+		if ch >= utf8.RuneSelf {
+			ch, width = utf8.DecodeRuneInString(l.input[i:])
+		}
+		if ch == '"' || ch == '\\' || ch == '\n' || ch == utf8.RuneError && width == 1 {
+			break
+		}
+		i += width
+		runes++
+	}
+
+	l.offset, l.col = i, l.col+runes
+	if i < len(l.input) && l.input[i] == '"' {
+		l.readChar()
+		return l.input[cur:i], true
+	}
+
+	return l.input[cur:i], false
+}
+
+// readString consumes a double-quoted string literal and returns its
+// contents with escape sequences resolved. Undecodable bytes are replaced by
+// U+FFFD. ok is false if the literal is unterminated or malformed.
+func (l *Lexer) readString(start token.Position) (literal string, ok bool) {
+	prefix, done := l.readPlainString()
+	if done {
+		return prefix, true
+	}
+
+	var out strings.Builder
+	out.WriteString(prefix)
+	ok = true
+
+	for {
+		l.readChar()
+
+		switch {
+		case l.ch == '"':
+			return out.String(), ok
+		case l.ch == 0 && l.pos >= len(l.input), l.ch == '\n':
+			l.error(start, "string literal not terminated")
+			return out.String(), false
+		case l.invalid():
+			ok = false
+		case l.ch == '\\':
+			pos := l.position()
+			l.readChar()
+
+			switch l.ch {
+			case 'n':
+				out.WriteByte('\n')
+			case 't':
+				out.WriteByte('\t')
+			case 'r':
+				out.WriteByte('\r')
+			case '0':
+				out.WriteByte(0)
+			case '"', '\\':
+				out.WriteRune(l.ch)
+			default:
+				if l.ch == 0 && l.pos >= len(l.input) {
+					continue
+				}
+				l.error(pos, "unknown escape sequence \\%c", l.ch)
+				ok = false
+			}
+			continue
+		}
+
+		out.WriteRune(l.ch)
+	}
+}
+
+// eatWhitespace skips blanks, stopping at a newline that must become a
+// SEMICOLON.
 func (l *Lexer) eatWhitespace() {
-	for l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r' {
+	for l.ch == ' ' || l.ch == '\t' || l.ch == '\n' && !l.insertSemi || l.ch == '\r' {
 		l.readChar()
 	}
 }
+
+// endsStatement reports whether a line ending right after a token of type tok
+// terminates the statement, following Go's semicolon insertion rules: after
+// an identifier, a literal, a closing bracket, a postfix ?, or a bare ret,
+// break or continue.
+func endsStatement(tok token.Token) bool {
+	switch tok {
+	case token.IDENT, token.INT, token.STRING, token.TRUE, token.FALSE,
+		token.RPAREN, token.RBRAC, token.RCURL, token.RET, token.BREAK, token.CONTINUE,
+		token.QUESTION:
+		return true
+	}
+
+	return false
+}
+
+// invalidSeparator returns the index of the first '_' in an integer literal
+// that does not sit between two digits (a base prefix counts as a digit), or
+// -1 if every separator is well placed.
+func invalidSeparator(literal string) int {
+	i, prev := 0, byte('.')
+
+	if len(literal) >= 2 && literal[0] == '0' {
+		if p := lower(literal[1]); p == 'x' || p == 'o' || p == 'b' {
+			i, prev = 2, '0'
+		}
+	}
+
+	for ; i < len(literal); i++ {
+		if literal[i] == '_' {
+			if prev != '0' {
+				return i
+			}
+			prev = '_'
+			continue
+		}
+		prev = '0'
+	}
+
+	if prev == '_' {
+		return len(literal) - 1
+	}
+
+	return -1
+}
+
+func isDigit(ch rune, base int) bool {
+	if ch >= utf8.RuneSelf {
+		return false
+	}
+	if base == 16 {
+		return digitValue(byte(ch)) < 16
+	}
+
+	// Octal and binary literals still consume every decimal digit so that a
+	// stray 8 or 9 is reported rather than starting a new token.
+	return '0' <= ch && ch <= '9'
+}
+
+func digitValue(ch byte) int {
+	switch {
+	case '0' <= ch && ch <= '9':
+		return int(ch - '0')
+	case 'a' <= lower(ch) && lower(ch) <= 'f':
+		return int(lower(ch) - 'a' + 10)
+	}
+
+	return 16
+}
+
+func lower(ch byte) byte {
+	return ch | ('x' - 'X')
+}
+
+func baseName(base int) string {
+	switch base {
+	case 2:
+		return "binary"
+	case 8:
+		return "octal"
+	case 16:
+		return "hexadecimal"
+	}
+
+	return "decimal"
+}
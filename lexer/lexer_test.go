@@ -1,8 +1,12 @@
 package lexer
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/0xedb/intlang/token"
 )
@@ -34,3 +38,360 @@ func TestNextToken(t *testing.T) {
 
 	t.Log("done")
 }
+
+func TestNumberLiterals(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"decimal", "1234"},
+		{"zero", "0"},
+		{"separated", "1_000_000"},
+		{"hex", "0xff"},
+		{"upper hex", "0XDEAD_beef"},
+		{"hex leading separator", "0x_ff"},
+		{"octal", "0o17"},
+		{"legacy octal", "017"},
+		{"binary", "0b1010"},
+		{"binary separated", "0b_1010_0101"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lex := New(test.input + ";")
+			tok := lex.NextToken()
+
+			if tok.Token != token.INT || tok.Literal != test.input {
+				t.Fatalf("Wanted: INT %q, Got: %s %q", test.input, tok.Token, tok.Literal)
+			}
+			if errs := lex.Errors(); len(errs) != 0 {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+			if next := lex.NextToken(); next.Token != token.SEMICOLON {
+				t.Fatalf("Wanted: ;, Got: %s", next.Token)
+			}
+		})
+	}
+}
+
+func TestMalformedNumberLiterals(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{"empty hex", "0x", "1:3: hexadecimal literal has no digits"},
+		{"only separator", "0b_", "1:3: binary literal has no digits"},
+		{"double separator", "1__0", "1:5: '_' must separate successive digits"},
+		{"trailing separator", "100_", "1:6: '_' must separate successive digits"},
+		{"leading zero separator", "0x1_", "1:6: '_' must separate successive digits"},
+		{"binary digit", "0b102", "1:7: invalid digit '2' in binary literal"},
+		{"octal digit", "0o78", "1:6: invalid digit '8' in octal literal"},
+		{"legacy octal digit", "09", "1:4: invalid digit '9' in octal literal"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lex := New("@ " + test.input)
+			lex.NextToken()
+			tok := lex.NextToken()
+
+			if tok.Token != token.ILLEGAL || tok.Literal != test.input {
+				t.Fatalf("Wanted: ILLEGAL %q, Got: %s %q", test.input, tok.Token, tok.Literal)
+			}
+			if errs := lex.Errors(); len(errs) != 1 || errs[0] != test.err {
+				t.Fatalf("Wanted: [%s], Got: %v", test.err, errs)
+			}
+		})
+	}
+}
+
+func TestPositions(t *testing.T) {
+	input := "@ x = 1;\n  x + 0x10"
+	want := []token.Position{
+		{Line: 1, Column: 1}, {Line: 1, Column: 3}, {Line: 1, Column: 5},
+		{Line: 1, Column: 7}, {Line: 1, Column: 8}, {Line: 2, Column: 3},
+		{Line: 2, Column: 5}, {Line: 2, Column: 7}, {Line: 2, Column: 11},
+	}
+
+	lex := New(input)
+	for i, pos := range want {
+		if tok := lex.NextToken(); tok.Pos != pos {
+			t.Fatalf("token %d (%q): Wanted: %s, Got: %s", i, tok.Literal, pos, tok.Pos)
+		}
+	}
+}
+
+func TestUnicode(t *testing.T) {
+	input := "@ größe = \"日本語\"; π2 + x٣;\n\"é\" café"
+	want := []token.TokenObj{
+		{Token: token.AT, Literal: "@", Pos: token.Position{Line: 1, Column: 1}},
+		{Token: token.IDENT, Literal: "größe", Pos: token.Position{Line: 1, Column: 3}},
+		{Token: token.ASSIGN, Literal: "=", Pos: token.Position{Line: 1, Column: 9}},
+		{Token: token.STRING, Literal: "日本語", Pos: token.Position{Line: 1, Column: 11}},
+		{Token: token.SEMICOLON, Literal: ";", Pos: token.Position{Line: 1, Column: 16}},
+		{Token: token.IDENT, Literal: "π2", Pos: token.Position{Line: 1, Column: 18}},
+		{Token: token.PLUS, Literal: "+", Pos: token.Position{Line: 1, Column: 21}},
+		{Token: token.IDENT, Literal: "x٣", Pos: token.Position{Line: 1, Column: 23}},
+		{Token: token.SEMICOLON, Literal: ";", Pos: token.Position{Line: 1, Column: 25}},
+		{Token: token.STRING, Literal: "é", Pos: token.Position{Line: 2, Column: 1}},
+		{Token: token.IDENT, Literal: "café", Pos: token.Position{Line: 2, Column: 5}},
+		{Token: token.SEMICOLON, Literal: "\n", Pos: token.Position{Line: 2, Column: 9}},
+		{Token: token.EOF, Literal: "", Pos: token.Position{Line: 2, Column: 9}},
+	}
+
+	lex := New(input)
+	for i, expect := range want {
+		if got := lex.NextToken(); got != expect {
+			t.Fatalf("token %d: Wanted: %+v, Got: %+v", i, expect, got)
+		}
+	}
+	if errs := lex.Errors(); len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+}
+
+func TestStringLiterals(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		expect token.TokenObj
+		errs   []string
+	}{
+		{"escapes", `"a\"b\\c\n"`, token.TokenObj{Token: token.STRING, Literal: "a\"b\\c\n"}, nil},
+		{"unterminated", `"abc`, token.TokenObj{Token: token.ILLEGAL, Literal: "abc"}, []string{"1:1: string literal not terminated"}},
+		{"unknown escape", `"a\qb"`, token.TokenObj{Token: token.ILLEGAL, Literal: "ab"}, []string{"1:3: unknown escape sequence \\q"}},
+		{"invalid utf8", "\"a\xffb\"", token.TokenObj{Token: token.ILLEGAL, Literal: "a�b"}, []string{"1:3: invalid UTF-8 encoding"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lex := New(test.input)
+			got := lex.NextToken()
+			got.Pos = token.Position{}
+
+			if got != test.expect {
+				t.Fatalf("Wanted: %+v, Got: %+v", test.expect, got)
+			}
+			if fmt.Sprint(lex.Errors()) != fmt.Sprint(test.errs) {
+				t.Fatalf("Wanted: %v, Got: %v", test.errs, lex.Errors())
+			}
+		})
+	}
+}
+
+func TestInvalidUTF8(t *testing.T) {
+	lex := New("ab \xc3 c")
+
+	for _, want := range []token.Token{token.IDENT, token.ILLEGAL, token.IDENT, token.SEMICOLON, token.EOF} {
+		if tok := lex.NextToken(); tok.Token != want {
+			t.Fatalf("Wanted: %s, Got: %s", want, tok.Token)
+		}
+	}
+
+	if errs := lex.Errors(); len(errs) != 1 || errs[0] != "1:4: invalid UTF-8 encoding" {
+		t.Fatalf("Wanted: [1:4: invalid UTF-8 encoding], Got: %v", errs)
+	}
+}
+
+func TestSemicolonInsertion(t *testing.T) {
+	input := `@ x = add(1,
+	2)
+ret
+@ y = [x] + "s"
+if (x) {
+	x
+} el { y }
+z +
+	1;
+@ v = f()?
+true`
+	want := []string{
+		"@", "x", "=", "add", "(", "1", ",", "2", ")", "\n",
+		"ret", "\n",
+		"@", "y", "=", "[", "x", "]", "+", "s", "\n",
+		"if", "(", "x", ")", "{", "x", "\n", "}", "el", "{", "y", "}", "\n",
+		"z", "+", "1", ";",
+		"@", "v", "=", "f", "(", ")", "?", "\n",
+		"true", "\n",
+	}
+
+	lex := New(input)
+	for i, literal := range want {
+		tok := lex.NextToken()
+		if tok.Literal != literal {
+			t.Fatalf("token %d: Wanted: %q, Got: %q", i, literal, tok.Literal)
+		}
+		if literal == "\n" && tok.Token != token.SEMICOLON {
+			t.Fatalf("token %d: Wanted: SEMICOLON, Got: %s", i, tok.Token)
+		}
+	}
+
+	if tok := lex.NextToken(); tok.Token != token.EOF {
+		t.Fatalf("Wanted: EOF, Got: %s %q", tok.Token, tok.Literal)
+	}
+}
+
+func TestNewReader(t *testing.T) {
+	input := benchInput(3*ReadSize) + "\"unterminated"
+	want := New(input)
+	lex := NewReader(iotest.HalfReader(strings.NewReader(input)))
+	window := 0
+
+	for {
+		expect := want.NextToken()
+		got, err := lex.Next()
+
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if got != expect {
+			t.Fatalf("Wanted: %+v, Got: %+v", expect, got)
+		}
+		if len(lex.input) > window {
+			window = len(lex.input)
+		}
+		if got.Token == token.EOF {
+			break
+		}
+	}
+
+	if fmt.Sprint(lex.Errors()) != fmt.Sprint(want.Errors()) {
+		t.Fatalf("Wanted: %v, Got: %v", want.Errors(), lex.Errors())
+	}
+	if window > ReadSize+len(benchSnippet) {
+		t.Fatalf("window grew to %d bytes", window)
+	}
+}
+
+func TestNewReaderSplitsRunes(t *testing.T) {
+	input := "@ größe = \"日本語\"; π2"
+	lex := NewReader(iotest.OneByteReader(strings.NewReader(input)))
+	want := New(input)
+
+	for expect := want.NextToken(); expect.Token != token.EOF; expect = want.NextToken() {
+		if got := lex.NextToken(); got != expect {
+			t.Fatalf("Wanted: %+v, Got: %+v", expect, got)
+		}
+	}
+	if errs := lex.Errors(); len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+}
+
+func TestNewReaderLongToken(t *testing.T) {
+	literal := strings.Repeat("ab", 1<<15)
+	input := "\"" + literal + "\""
+
+	allocs := testing.AllocsPerRun(1, func() {
+		lex := NewReader(iotest.OneByteReader(strings.NewReader(input)))
+		if tok := lex.NextToken(); tok.Token != token.STRING || tok.Literal != literal {
+			t.Fatalf("Wanted: STRING of %d bytes, Got: %s of %d bytes", len(literal), tok.Token, len(tok.Literal))
+		}
+	})
+
+	// Appending every read to the window would allocate once per byte.
+	if allocs > 100 {
+		t.Fatalf("reading a %d byte token allocated %.0f times", len(input), allocs)
+	}
+}
+
+func TestNewReaderError(t *testing.T) {
+	boom := errors.New("boom")
+	lex := NewReader(io.MultiReader(strings.NewReader("a b"), iotest.ErrReader(boom)))
+
+	for _, want := range []token.Token{token.IDENT, token.IDENT, token.SEMICOLON} {
+		if tok, err := lex.Next(); tok.Token != want || err != nil {
+			t.Fatalf("Wanted: %s, Got: %s (%v)", want, tok.Token, err)
+		}
+	}
+
+	if tok, err := lex.Next(); tok.Token != token.EOF || err != boom {
+		t.Fatalf("Wanted: EOF (boom), Got: %s (%v)", tok.Token, err)
+	}
+	if tok, err := lex.Next(); tok.Token != token.EOF || err != nil {
+		t.Fatalf("Wanted: EOF, Got: %s (%v)", tok.Token, err)
+	}
+}
+
+func TestNextTokenDoesNotAllocate(t *testing.T) {
+	lex := New(benchInput(1 << 12))
+
+	allocs := testing.AllocsPerRun(100, func() {
+		if lex.NextToken().Token == token.EOF {
+			t.Fatal("ran out of input")
+		}
+	})
+	if allocs != 0 {
+		t.Fatalf("Wanted: 0 allocations per token, Got: %v", allocs)
+	}
+}
+
+const benchSnippet = `
+@ fibonacci = fn(n) {
+	if (n < 2) { ret n; } el { ret fibonacci(n - 1) + fibonacci(n - 2); }
+};
+@ greeting = "hello, wörld";
+@ mask = 0xff_ff + 0b1010 * 1_000_000;
+@ result = fibonacci(mask / 100) != greeting;
+`
+
+// benchInput repeats benchSnippet until it is at least size bytes long.
+func benchInput(size int) string {
+	return strings.Repeat(benchSnippet, size/len(benchSnippet)+1)
+}
+
+func benchmarkLexer(b *testing.B, input string) {
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		lex := New(input)
+		for tok := lex.NextToken(); tok.Token != token.EOF; tok = lex.NextToken() {
+		}
+	}
+}
+
+func BenchmarkNextToken1MB(b *testing.B) {
+	benchmarkLexer(b, benchInput(1<<20))
+}
+
+func BenchmarkNextToken8MB(b *testing.B) {
+	benchmarkLexer(b, benchInput(8<<20))
+}
+
+func BenchmarkNewReader8MB(b *testing.B) {
+	input := benchInput(8 << 20)
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		lex := NewReader(strings.NewReader(input))
+		for tok := lex.NextToken(); tok.Token != token.EOF; tok = lex.NextToken() {
+		}
+	}
+}
+
+func BenchmarkIdentifiers(b *testing.B) {
+	benchmarkLexer(b, strings.Repeat("alpha beta_2 gamma fn ret if el δέλτα ", 1<<15))
+}
+
+func TestOperators(t *testing.T) {
+	input := "= == ! != + += - -= * *= / /= % %= . .. ... => ? ?. ?[ ?? ??? | |>"
+	want := []token.Token{
+		token.ASSIGN, token.EQL, token.NOT, token.NEQL,
+		token.PLUS, token.PLUS_ASSIGN, token.MINUS, token.MINUS_ASSIGN,
+		token.MULT, token.MULT_ASSIGN, token.DIV, token.DIV_ASSIGN,
+		token.MOD, token.MOD_ASSIGN, token.DOT, token.DOTDOT, token.ELLIPSIS,
+		token.ARROW, token.QUESTION, token.OPTIONAL_DOT, token.OPTIONAL_LBRAC,
+		token.NULLISH, token.NULLISH, token.QUESTION, token.PIPE, token.PIPE_GT, token.EOF,
+	}
+
+	lex := New(input)
+	for i, expect := range want {
+		if tok := lex.NextToken(); tok.Token != expect {
+			t.Fatalf("token %d: Wanted: %s, Got: %s %q", i, expect, tok.Token, tok.Literal)
+		}
+	}
+}
@@ -1,11 +1,29 @@
 package main
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/0xedb/intlang/repl"
 )
 
+// With a file argument, intlang runs the program in it; without one, it
+// starts the REPL.
 func main() {
-	repl.StartREPL(os.Stdout, os.Stdout)
+	if len(os.Args) < 2 {
+		repl.StartREPL(os.Stdout, os.Stdout)
+		return
+	}
+
+	f, err := os.Open(os.Args[1])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	ok := repl.Run(f, os.Stderr)
+	f.Close()
+	if !ok {
+		os.Exit(1)
+	}
 }
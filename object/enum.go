@@ -0,0 +1,60 @@
+package object
+
+import "strings"
+
+// Enum is a declared tagged union.
+type Enum struct {
+	Name     string
+	Variants []*Variant
+	Methods  Methods
+}
+
+func (e *Enum) Type() ObjectType { return ENUM_TYPE_OBJ }
+func (e *Enum) Inspect() string {
+	variants := make([]string, len(e.Variants))
+	for i, v := range e.Variants {
+		variants[i] = v.signature()
+	}
+
+	return "enum " + e.Name + " { " + strings.Join(variants, ", ") + " }"
+}
+
+// Variant is one variant of an enum. A variant with fields is called like a
+// function to construct a value of it.
+type Variant struct {
+	Enum   *Enum
+	Name   string
+	Fields []string // nil for a variant without fields
+}
+
+func (v *Variant) Type() ObjectType { return VARIANT_OBJ }
+func (v *Variant) Inspect() string  { return "variant " + v.Enum.Name + "." + v.signature() }
+
+func (v *Variant) signature() string {
+	if v.Fields == nil {
+		return v.Name
+	}
+
+	return v.Name + "(" + strings.Join(v.Fields, ", ") + ")"
+}
+
+// EnumValue is a value of an enum: a variant with a value for each of its
+// fields.
+type EnumValue struct {
+	Variant *Variant
+	Values  []Object
+}
+
+func (ev *EnumValue) Type() ObjectType { return ENUM_OBJ }
+func (ev *EnumValue) Inspect() string {
+	if ev.Variant.Fields == nil {
+		return ev.Variant.Name
+	}
+
+	values := make([]string, len(ev.Values))
+	for i, v := range ev.Values {
+		values[i] = v.Inspect()
+	}
+
+	return ev.Variant.Name + "(" + strings.Join(values, ", ") + ")"
+}
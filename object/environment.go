@@ -0,0 +1,79 @@
+package object
+
+import "github.com/0xedb/intlang/token"
+
+// Binding is what an Environment stores for a name. Only mutable bindings
+// may be reassigned; Pos records where the binding was declared so that
+// errors can point at it.
+type Binding struct {
+	Value   Object
+	Mutable bool
+	Pos     token.Position
+}
+
+type Environment struct {
+	store map[string]*Binding
+	outer *Environment
+
+	// Evaluation is the state the evaluator keeps for the evaluation the
+	// environment belongs to, such as its call stack. Enclosed
+	// environments share it with the one enclosing them.
+	Evaluation interface{}
+}
+
+func NewEnvironment() *Environment {
+	return &Environment{store: map[string]*Binding{}}
+}
+
+// NewEnclosedEnvironment returns a scope nested inside outer, such as the
+// body of a function call.
+func NewEnclosedEnvironment(outer *Environment) *Environment {
+	env := NewEnvironment()
+	env.outer = outer
+	env.Evaluation = outer.Evaluation
+
+	return env
+}
+
+// Get looks name up in this scope and then in the enclosing ones.
+func (e *Environment) Get(name string) (Object, bool) {
+	if b, ok := e.Lookup(name); ok {
+		return b.Value, true
+	}
+
+	return nil, false
+}
+
+// Lookup returns the binding for name from the innermost scope declaring
+// it. Assigning to its Value updates the variable in that scope.
+func (e *Environment) Lookup(name string) (*Binding, bool) {
+	for env := e; env != nil; env = env.outer {
+		if b, ok := env.store[name]; ok {
+			return b, true
+		}
+	}
+
+	return nil, false
+}
+
+// Set binds name to a mutable value in this scope, shadowing any binding in
+// enclosing scopes. It is used for parameters and loop variables.
+func (e *Environment) Set(name string, val Object) Object {
+	e.store[name] = &Binding{Value: val, Mutable: true}
+
+	return val
+}
+
+// Declare binds name in this scope as an @ declaration at pos does. A name
+// can only be declared once in a scope: if this scope already declares name,
+// Declare leaves it bound as it was and returns its binding and false.
+func (e *Environment) Declare(name string, val Object, mutable bool, pos token.Position) (*Binding, bool) {
+	if b, ok := e.store[name]; ok {
+		return b, false
+	}
+
+	b := &Binding{Value: val, Mutable: mutable, Pos: pos}
+	e.store[name] = b
+
+	return b, true
+}
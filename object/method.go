@@ -0,0 +1,16 @@
+package object
+
+// Methods holds the methods declared for a struct or enum by impl blocks.
+type Methods map[string]*Function
+
+// BoundMethod is a method looked up on a value: calling it calls Method with
+// Receiver as its first argument.
+type BoundMethod struct {
+	Receiver Object
+	Method   *Function
+}
+
+func (bm *BoundMethod) Type() ObjectType { return METHOD_OBJ }
+func (bm *BoundMethod) Inspect() string {
+	return "method " + bm.Method.Name
+}
@@ -1,11 +1,37 @@
 package object
 
-import "fmt"
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	"github.com/0xedb/intlang/ast"
+	"github.com/0xedb/intlang/token"
+)
 
 const (
-	INTEGER_OBJ = "INTEGER"
-	BOOLEAN_OBJ = "BOOLEAN"
-	NULL_OBJ    = "NULL"
+	INTEGER_OBJ      = "INTEGER"
+	BOOLEAN_OBJ      = "BOOLEAN"
+	NULL_OBJ         = "NULL"
+	STRING_OBJ       = "STRING"
+	ARRAY_OBJ        = "ARRAY"
+	HASH_OBJ         = "HASH"
+	RANGE_OBJ        = "RANGE"
+	FUNCTION_OBJ     = "FUNCTION"
+	BUILTIN_OBJ      = "BUILTIN"
+	METHOD_OBJ       = "METHOD"
+	ENUM_TYPE_OBJ    = "ENUM_TYPE"
+	ENUM_OBJ         = "ENUM"
+	VARIANT_OBJ      = "VARIANT"
+	STRUCT_TYPE_OBJ  = "STRUCT_TYPE"
+	STRUCT_OBJ       = "STRUCT"
+	RESULT_OBJ       = "RESULT"
+	RETURN_VALUE_OBJ = "RETURN_VALUE"
+	BREAK_OBJ        = "BREAK"
+	CONTINUE_OBJ     = "CONTINUE"
+	TAIL_CALL_OBJ    = "TAIL_CALL"
+	ERROR_OBJ        = "ERROR"
+	EXCEPTION_OBJ    = "EXCEPTION"
 )
 
 type ObjectType string
@@ -15,12 +41,41 @@ type Object interface {
 	Inspect() string
 }
 
+// TypeName returns the name of the type of obj as messages show it: the name
+// of the struct or enum for a user-defined value, which only has a fixed tag
+// as its Type so that a user-defined name cannot pass for a built-in type,
+// and the tag otherwise.
+func TypeName(obj Object) string {
+	switch obj := obj.(type) {
+	case *Struct:
+		return obj.Def.Name
+	case *EnumValue:
+		return obj.Variant.Enum.Name
+	}
+
+	return string(obj.Type())
+}
+
+// Hashable is implemented by objects that may be used as hash keys.
+type Hashable interface {
+	Object
+	HashKey() HashKey
+}
+
+type HashKey struct {
+	Type  ObjectType
+	Value uint64
+}
+
 type Integer struct {
 	Value int64
 }
 
 func (i *Integer) Type() ObjectType { return INTEGER_OBJ }
 func (i *Integer) Inspect() string  { return fmt.Sprintf("%d", i.Value) }
+func (i *Integer) HashKey() HashKey {
+	return HashKey{Type: i.Type(), Value: uint64(i.Value)}
+}
 
 type Boolean struct {
 	Value bool
@@ -28,8 +83,252 @@ type Boolean struct {
 
 func (b *Boolean) Type() ObjectType { return BOOLEAN_OBJ }
 func (b *Boolean) Inspect() string  { return fmt.Sprintf("%t", b.Value) }
+func (b *Boolean) HashKey() HashKey {
+	if b.Value {
+		return HashKey{Type: b.Type(), Value: 1}
+	}
+
+	return HashKey{Type: b.Type(), Value: 0}
+}
 
 type Null struct{}
 
 func (n *Null) Type() ObjectType { return NULL_OBJ }
 func (n *Null) Inspect() string  { return "null" }
+
+type String struct {
+	Value string
+}
+
+func (s *String) Type() ObjectType { return STRING_OBJ }
+func (s *String) Inspect() string  { return s.Value }
+func (s *String) HashKey() HashKey {
+	h := fnv.New64a()
+	h.Write([]byte(s.Value))
+
+	return HashKey{Type: s.Type(), Value: h.Sum64()}
+}
+
+type Array struct {
+	Elements []Object
+}
+
+func (a *Array) Type() ObjectType { return ARRAY_OBJ }
+func (a *Array) Inspect() string {
+	elements := make([]string, len(a.Elements))
+	for i, e := range a.Elements {
+		elements[i] = e.Inspect()
+	}
+
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+type HashPair struct {
+	Key   Object
+	Value Object
+}
+
+// Hash maps keys to values and remembers the order in which keys were first
+// added, so that printing and iterating over a hash are deterministic.
+type Hash struct {
+	Pairs map[HashKey]HashPair
+	Keys  []HashKey
+}
+
+func NewHash() *Hash {
+	return &Hash{Pairs: map[HashKey]HashPair{}}
+}
+
+// Set adds or replaces the value stored under key.
+func (h *Hash) Set(key Hashable, value Object) {
+	h.Put(key.HashKey(), key, value)
+}
+
+// Put adds or replaces the value stored under key at hashKey, which the
+// caller has worked out for a key that is not Hashable itself.
+func (h *Hash) Put(hashKey HashKey, key, value Object) {
+	if _, ok := h.Pairs[hashKey]; !ok {
+		h.Keys = append(h.Keys, hashKey)
+	}
+
+	h.Pairs[hashKey] = HashPair{Key: key, Value: value}
+}
+
+func (h *Hash) Type() ObjectType { return HASH_OBJ }
+func (h *Hash) Inspect() string {
+	pairs := make([]string, len(h.Keys))
+	for i, key := range h.Keys {
+		pair := h.Pairs[key]
+		pairs[i] = pair.Key.Inspect() + ": " + pair.Value.Inspect()
+	}
+
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+// Range is the half-open interval of integers [Start, End) produced by the
+// .. operator. It is never materialised, so iterating over a large range
+// costs no memory.
+type Range struct {
+	Start, End int64
+}
+
+func (r *Range) Type() ObjectType { return RANGE_OBJ }
+func (r *Range) Inspect() string  { return fmt.Sprintf("%d..%d", r.Start, r.End) }
+
+// Len returns the number of integers in the range.
+func (r *Range) Len() int64 {
+	if r.End < r.Start {
+		return 0
+	}
+
+	return r.End - r.Start
+}
+
+type Function struct {
+	Name       string // empty for anonymous functions
+	Parameters []*ast.Parameter
+	Body       *ast.BlockStatement
+	Env        *Environment
+}
+
+func (f *Function) Type() ObjectType { return FUNCTION_OBJ }
+func (f *Function) Inspect() string {
+	params := make([]string, len(f.Parameters))
+	for i, p := range f.Parameters {
+		params[i] = p.String()
+	}
+
+	name := ""
+	if f.Name != "" {
+		name = " " + f.Name
+	}
+
+	return "fn" + name + "(" + strings.Join(params, ", ") + ") " + f.Body.String()
+}
+
+// BuiltinFunction is the Go implementation of a builtin; env is the
+// environment it is called in.
+type BuiltinFunction func(env *Environment, args ...Object) Object
+
+type Builtin struct {
+	Fn BuiltinFunction
+}
+
+func (b *Builtin) Type() ObjectType { return BUILTIN_OBJ }
+func (b *Builtin) Inspect() string  { return "builtin function" }
+
+type ReturnValue struct {
+	Value Object
+}
+
+func (rv *ReturnValue) Type() ObjectType { return RETURN_VALUE_OBJ }
+func (rv *ReturnValue) Inspect() string  { return rv.Value.Inspect() }
+
+// Break and Continue carry a break or continue statement out of the blocks
+// nested inside a loop. Label is empty when the statement targets the
+// innermost loop.
+type Break struct {
+	Label string
+}
+
+func (b *Break) Type() ObjectType { return BREAK_OBJ }
+func (b *Break) Inspect() string  { return "break" }
+
+type Continue struct {
+	Label string
+}
+
+func (c *Continue) Type() ObjectType { return CONTINUE_OBJ }
+func (c *Continue) Inspect() string  { return "continue" }
+
+// TailCall carries a call in tail position out of the body of the function
+// making it, so that the call replaces that function's call rather than
+// nesting inside it. Its function and arguments have been evaluated already.
+type TailCall struct {
+	Function Object
+	Args     []Object
+	Named    []NamedArgument
+	Site     token.Position
+}
+
+func (tc *TailCall) Type() ObjectType { return TAIL_CALL_OBJ }
+func (tc *TailCall) Inspect() string  { return "tail call" }
+
+// Error is a runtime error unwinding the evaluation, either raised by the
+// evaluator or thrown by the program.
+type Error struct {
+	Message string
+	Kind    ErrorKind
+	Pos     token.Position // where it was raised; the zero Position until known
+	Value   Object         // the thrown value, nil for an error raised by the evaluator
+	Frames  []Frame        // the calls being evaluated when it was raised, innermost first
+}
+
+func (e *Error) Type() ObjectType { return ERROR_OBJ }
+func (e *Error) Inspect() string  { return "ERROR: " + e.Message }
+
+// Trace formats the error the way Go prints a panic: the message, then every
+// call that was being evaluated, innermost first, each with the position it
+// had reached.
+func (e *Error) Trace() string {
+	var out strings.Builder
+	fmt.Fprintf(&out, "error: %s [%s]\n\n", e.Message, e.Kind)
+
+	pos := e.Pos
+	for _, f := range e.Frames {
+		fmt.Fprintf(&out, "%s(...)\n\t%s\n", f.Function, pos)
+		pos = f.Site
+		if f.Elided > 0 {
+			fmt.Fprintf(&out, "...%d %s elided by tail calls\n\t%s\n", f.Elided, plural(f.Elided, "call"), pos)
+			pos = f.Entry
+		}
+	}
+	fmt.Fprintf(&out, "<main>\n\t%s\n", pos)
+
+	return out.String()
+}
+
+// Frame is a call of a function that was being evaluated when an error was
+// raised. If Elided is not zero, Function was called by a tail call, which
+// replaced that many calls, the first of which was made at Entry.
+type Frame struct {
+	Function string         // the name of the function, or <anonymous>
+	Site     token.Position // where it was called
+	Elided   int
+	Entry    token.Position
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return word
+	}
+
+	return word + "s"
+}
+
+// ErrorKind classifies runtime errors, so that a program catching one can
+// tell what went wrong.
+type ErrorKind string
+
+const (
+	ArgumentError   ErrorKind = "ArgumentError"
+	ArithmeticError ErrorKind = "ArithmeticError"
+	AssignmentError ErrorKind = "AssignmentError"
+	IndexError      ErrorKind = "IndexError"
+	MatchError      ErrorKind = "MatchError"
+	MemberError     ErrorKind = "MemberError"
+	NameError       ErrorKind = "NameError"
+	TypeError       ErrorKind = "TypeError"
+	ResultError     ErrorKind = "ResultError"
+	ThrownError     ErrorKind = "ThrownError"
+)
+
+// Exception is a caught runtime error raised by the evaluator, as seen by
+// the catch clause: a value with message, kind and position fields. Throwing
+// it again rethrows the original error.
+type Exception struct {
+	Err *Error
+}
+
+func (e *Exception) Type() ObjectType { return EXCEPTION_OBJ }
+func (e *Exception) Inspect() string  { return string(e.Err.Kind) + ": " + e.Err.Message }
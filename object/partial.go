@@ -0,0 +1,35 @@
+package object
+
+import "strings"
+
+// NamedArgument is an argument passed by parameter name.
+type NamedArgument struct {
+	Name  string
+	Value Object
+}
+
+// Partial is a function made by calling Function with placeholders for some
+// of its arguments, `add(1, _)`. Calling it calls Function with Args, the
+// nil placeholders filled in order by the arguments it is called with.
+type Partial struct {
+	Function Object
+	Args     []Object
+	Named    []NamedArgument
+}
+
+func (p *Partial) Type() ObjectType { return FUNCTION_OBJ }
+func (p *Partial) Inspect() string {
+	args := make([]string, 0, len(p.Args)+len(p.Named))
+	for _, arg := range p.Args {
+		if arg == nil {
+			args = append(args, "_")
+		} else {
+			args = append(args, arg.Inspect())
+		}
+	}
+	for _, arg := range p.Named {
+		args = append(args, arg.Name+": "+arg.Value.Inspect())
+	}
+
+	return p.Function.Inspect() + "(" + strings.Join(args, ", ") + ")"
+}
@@ -0,0 +1,17 @@
+package object
+
+// Result is the outcome of an operation that may fail: ok(value) or
+// err(error).
+type Result struct {
+	Ok    bool
+	Value Object
+}
+
+func (r *Result) Type() ObjectType { return RESULT_OBJ }
+func (r *Result) Inspect() string {
+	if r.Ok {
+		return "ok(" + r.Value.Inspect() + ")"
+	}
+
+	return "err(" + r.Value.Inspect() + ")"
+}
@@ -0,0 +1,44 @@
+package object
+
+import "strings"
+
+// StructType is a declared struct: a name and a fixed set of fields.
+type StructType struct {
+	Name    string
+	Fields  []string
+	Methods Methods
+}
+
+func (st *StructType) Type() ObjectType { return STRUCT_TYPE_OBJ }
+func (st *StructType) Inspect() string {
+	return "struct " + st.Name + " { " + strings.Join(st.Fields, ", ") + " }"
+}
+
+// FieldIndex returns the position of the named field, or -1 if the struct
+// has no such field.
+func (st *StructType) FieldIndex(name string) int {
+	for i, f := range st.Fields {
+		if f == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Struct is a value of a struct type, holding a value for each of its
+// fields in declaration order.
+type Struct struct {
+	Def    *StructType
+	Values []Object
+}
+
+func (s *Struct) Type() ObjectType { return STRUCT_OBJ }
+func (s *Struct) Inspect() string {
+	fields := make([]string, len(s.Values))
+	for i, v := range s.Values {
+		fields[i] = s.Def.Fields[i] + ": " + v.Inspect()
+	}
+
+	return s.Def.Name + " { " + strings.Join(fields, ", ") + " }"
+}
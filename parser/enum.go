@@ -0,0 +1,127 @@
+package parser
+
+import (
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/0xedb/intlang/ast"
+	"github.com/0xedb/intlang/token"
+)
+
+// parseEnumStatement parses `enum Name { Variant(fields), Variant, ... }`,
+// with variants separated by commas or newlines.
+func (p *Parser) parseEnumStatement() *ast.EnumStatement {
+	stmt := &ast.EnumStatement{Token: p.cur}
+
+	if !p.expectToken(token.IDENT) {
+		return nil
+	}
+	stmt.Name = &ast.Identifier{Token: p.cur, Value: p.cur.Literal}
+
+	if !p.expectToken(token.LCURL) {
+		return nil
+	}
+	seen := map[string]bool{}
+
+	ok := p.parseBraced("enum", "enum variant", func() bool {
+		if !p.curTokenIs(token.IDENT) {
+			msg := fmt.Sprintf("%s: expected an enum variant, but got %s", p.cur.Pos, describe(p.cur))
+			p.errors = append(p.errors, msg)
+			return false
+		}
+
+		variant := &ast.EnumVariant{Name: &ast.Identifier{Token: p.cur, Value: p.cur.Literal}}
+		p.checkVariantName(variant.Name, stmt.Name, seen)
+
+		if p.peekTokenIs(token.LPAREN) {
+			p.nextToken()
+			if variant.Fields = p.parseIdentifierList(token.RPAREN); variant.Fields == nil {
+				return false
+			}
+			p.checkDuplicates(variant.Fields, map[string]bool{}, "field")
+		}
+
+		stmt.Variants = append(stmt.Variants, variant)
+		return true
+	})
+	if !ok || !p.endStatement() {
+		return nil
+	}
+
+	return stmt
+}
+
+func (p *Parser) checkVariantName(name, enum *ast.Identifier, seen map[string]bool) {
+	var msg string
+	switch {
+	case !isUpper(name.Value):
+		msg = fmt.Sprintf("%s: variant %s of enum %s must start with an upper-case letter", name.Token.Pos, name.Value, enum.Value)
+	case seen[name.Value]:
+		msg = fmt.Sprintf("%s: duplicate variant %s in enum %s", name.Token.Pos, name.Value, enum.Value)
+	}
+	seen[name.Value] = true
+
+	if msg != "" {
+		p.errors = append(p.errors, msg)
+	}
+}
+
+// isUpper reports whether name starts with an upper-case letter, as the
+// names of enum variants do.
+func isUpper(name string) bool {
+	r, _ := utf8.DecodeRuneInString(name)
+	return unicode.IsUpper(r)
+}
+
+// parseIdentifierList parses comma-separated names up to the closing end
+// token, allowing a trailing comma. It returns nil if the list is malformed.
+func (p *Parser) parseIdentifierList(end token.Token) []*ast.Identifier {
+	list := []*ast.Identifier{}
+
+	for !p.peekTokenIs(end) {
+		if !p.expectToken(token.IDENT) {
+			return nil
+		}
+		list = append(list, &ast.Identifier{Token: p.cur, Value: p.cur.Literal})
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+
+	if !p.expectToken(end) {
+		return nil
+	}
+
+	return list
+}
+
+// parseVariantPattern parses `Variant(patterns)` starting at the variant's
+// name.
+func (p *Parser) parseVariantPattern() ast.Pattern {
+	pattern := &ast.VariantPattern{Token: p.cur, Name: &ast.Identifier{Token: p.cur, Value: p.cur.Literal}}
+	pattern.Fields = []ast.Pattern{}
+	p.nextToken()
+
+	for !p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		field := p.parsePattern()
+		if field == nil {
+			return nil
+		}
+		pattern.Fields = append(pattern.Fields, field)
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+
+	if !p.expectToken(token.RPAREN) {
+		return nil
+	}
+
+	return pattern
+}
@@ -0,0 +1,76 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/0xedb/intlang/ast"
+	"github.com/0xedb/intlang/token"
+)
+
+// parseArrowFunction parses the body of an arrow function, `x => x * 2`,
+// whose parameters have been parsed already, into a function literal that
+// returns the body.
+func (p *Parser) parseArrowFunction(params []*ast.Parameter) ast.Expression {
+	lit := &ast.FunctionLiteral{Token: p.cur, Parameters: params}
+	if lit.Parameters == nil {
+		lit.Parameters = []*ast.Parameter{}
+	}
+	p.nextToken()
+
+	var body ast.Expression
+	p.inFunction(func() { body = p.parseExpression(token.LOWEST) })
+	if body == nil {
+		return nil
+	}
+	ret := &ast.ReturnStatement{Token: lit.Token, ReturnValue: body}
+	lit.Body = &ast.BlockStatement{Token: lit.Token, Statements: []ast.Statement{ret}}
+
+	markTailCalls(lit.Body)
+
+	return lit
+}
+
+// arrowParameters turns the parenthesized expressions before a => into the
+// parameters of an arrow function: names, optionally with defaults, `(a,
+// b = 1)`. starts holds the position at which each expression starts.
+func (p *Parser) arrowParameters(exps []ast.Expression, starts []token.Position) []*ast.Parameter {
+	params := []*ast.Parameter{}
+	seen := map[string]bool{}
+
+	for i, exp := range exps {
+		param := &ast.Parameter{}
+		switch exp := exp.(type) {
+		case *ast.Identifier:
+			param.Target = exp
+		case *ast.AssignExpression:
+			if target, ok := exp.Target.(*ast.Identifier); ok && exp.Operator == "=" {
+				param.Target, param.Default = target, exp.Value
+			}
+		case nil:
+			return nil
+		}
+
+		if param.Target == nil {
+			msg := fmt.Sprintf("%s: expected a parameter, but got %s", starts[i], exps[i])
+			p.errors = append(p.errors, msg)
+			return nil
+		}
+
+		p.checkDuplicates(ast.PatternNames(param.Target), seen, "parameter")
+		p.checkParameter(starts[i], param, params)
+		params = append(params, param)
+	}
+
+	return params
+}
+
+// parseArgument parses a positional argument of a call, which may be a
+// placeholder, `_`, for an argument to be passed later.
+func (p *Parser) parseArgument() ast.Expression {
+	if p.curTokenIs(token.IDENT) && p.cur.Literal == "_" &&
+		(p.peekTokenIs(token.COMMA) || p.peekTokenIs(token.RPAREN)) {
+		return &ast.Placeholder{Token: p.cur}
+	}
+
+	return p.parseElement()
+}
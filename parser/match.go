@@ -0,0 +1,72 @@
+package parser
+
+import (
+	"github.com/0xedb/intlang/ast"
+	"github.com/0xedb/intlang/token"
+)
+
+// parseMatchExpression parses `match (value) { pattern => body, ... }`. Arms
+// are separated by commas or newlines, and a body starting with { is a
+// block rather than a hash literal.
+func (p *Parser) parseMatchExpression() ast.Expression {
+	match := &ast.MatchExpression{Token: p.cur}
+
+	if !p.expectToken(token.LPAREN) {
+		return nil
+	}
+	p.nextToken()
+	match.Value = p.parseExpression(token.LOWEST)
+	if !p.expectToken(token.RPAREN) || !p.expectToken(token.LCURL) {
+		return nil
+	}
+
+	ok := p.parseBraced("match", "match arm", func() bool {
+		arm := p.parseMatchArm()
+		if arm == nil {
+			return false
+		}
+		match.Arms = append(match.Arms, arm)
+		return true
+	})
+	if !ok {
+		return nil
+	}
+
+	return match
+}
+
+func (p *Parser) parseMatchArm() *ast.MatchArm {
+	arm := &ast.MatchArm{Token: p.cur}
+
+	inArm := p.inArm
+	p.inArm = true
+	arm.Pattern = p.parsePattern()
+	p.inArm = inArm
+
+	if arm.Pattern == nil {
+		return nil
+	}
+	p.checkDuplicates(ast.PatternNames(arm.Pattern), map[string]bool{}, "binding")
+
+	if p.peekTokenIs(token.IF) {
+		p.nextToken()
+		p.nextToken()
+		inGuard := p.inGuard
+		p.inGuard = true
+		arm.Guard = p.parseExpression(token.LOWEST)
+		p.inGuard = inGuard
+	}
+
+	if !p.expectToken(token.ARROW) {
+		return nil
+	}
+	p.nextToken()
+
+	if p.curTokenIs(token.LCURL) {
+		arm.Body = p.parseBlockStatement()
+	} else if body := p.parseExpression(token.LOWEST); body != nil {
+		arm.Body = body
+	}
+
+	return arm
+}
@@ -5,7 +5,6 @@ import (
 	"strconv"
 
 	"github.com/0xedb/intlang/ast"
-	"github.com/0xedb/intlang/lexer"
 	"github.com/0xedb/intlang/token"
 )
 
@@ -14,21 +13,56 @@ type (
 	infixParseFn  func(ast.Expression) ast.Expression
 )
 
+// Source supplies the tokens a Parser consumes, ending with an EOF token.
+// An error returned alongside a token is recorded as a parse error.
+// *lexer.Lexer is the usual implementation, either over a string or
+// streaming from an io.Reader.
+type Source interface {
+	Next() (token.TokenObj, error)
+}
+
+// errorSource is implemented by sources that collect their own diagnostics,
+// such as *lexer.Lexer.
+type errorSource interface {
+	Errors() []string
+}
+
 type Parser struct {
-	lexer     *lexer.Lexer
+	source    Source
 	errors    []string
 	cur, peek token.TokenObj
 
+	// loops holds the labels of the loops enclosing the current token within
+	// the current function, innermost last; unlabelled loops have "".
+	loops []string
+
+	// inArm is set while parsing the pattern of a match arm, where a bare
+	// name starting with an upper-case letter stands for an enum variant.
+	inArm bool
+
+	// inGuard is set while parsing the guard of a match arm, outside any
+	// parentheses, where => ends the guard rather than starting an arrow
+	// function.
+	inGuard bool
+
+	// tails is set while parsing the body of a function outside any try,
+	// where the call a ret statement returns is a tail call.
+	tails bool
+
+	// structs holds the fields of the structs declared so far, by name.
+	structs map[string][]string
+
 	infixFn  map[token.Token]infixParseFn
 	prefixFn map[token.Token]prefixParseFn
 }
 
-func New(l *lexer.Lexer) *Parser {
+func New(source Source) *Parser {
 	p := &Parser{
-		lexer:    l,
+		source:   source,
 		errors:   []string{},
 		infixFn:  map[token.Token]infixParseFn{},
 		prefixFn: map[token.Token]prefixParseFn{},
+		structs:  map[string][]string{},
 	}
 
 	p.registerPrefix(token.IDENT, p.parseIdentifier)
@@ -38,45 +72,215 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.TRUE, p.parseBoolean)
 	p.registerPrefix(token.FALSE, p.parseBoolean)
 	p.registerPrefix(token.IF, p.parseIfExpression)
+	p.registerPrefix(token.MATCH, p.parseMatchExpression)
+	p.registerPrefix(token.TRY, p.parseTryExpression)
 	p.registerPrefix(token.LPAREN, p.parseGroupedExpression)
 	p.registerPrefix(token.FUNCTION, p.parseFunctionLiteral)
+	p.registerPrefix(token.STRING, p.parseStringLiteral)
+	p.registerPrefix(token.LBRAC, p.parseArrayLiteral)
+	p.registerPrefix(token.LCURL, p.parseHashLiteral)
+	p.registerPrefix(token.ELLIPSIS, p.parseMisplacedSpread)
+	p.registerPrefix(token.ILLEGAL, p.parseIllegal)
 	p.registerInfix(token.LPAREN, p.parseCallExpression)
+	p.registerInfix(token.LBRAC, p.parseIndexExpression)
+	p.registerInfix(token.DOT, p.parseMemberExpression)
+	p.registerInfix(token.OPTIONAL_DOT, p.parseOptionalExpression)
+	p.registerInfix(token.OPTIONAL_LBRAC, p.parseIndexExpression)
+	p.registerInfix(token.QUESTION, p.parsePropagateExpression)
 
 	p.registerInfix(token.PLUS, p.parseInfixExpression)
 	p.registerInfix(token.MINUS, p.parseInfixExpression)
 	p.registerInfix(token.DIV, p.parseInfixExpression)
 	p.registerInfix(token.MULT, p.parseInfixExpression)
+	p.registerInfix(token.MOD, p.parseInfixExpression)
 	p.registerInfix(token.EQL, p.parseInfixExpression)
 	p.registerInfix(token.NEQL, p.parseInfixExpression)
 	p.registerInfix(token.LST, p.parseInfixExpression)
 	p.registerInfix(token.GRT, p.parseInfixExpression)
+	p.registerInfix(token.DOTDOT, p.parseInfixExpression)
+	p.registerInfix(token.NULLISH, p.parseInfixExpression)
+	p.registerInfix(token.PIPE_GT, p.parsePipeExpression)
+
+	p.registerInfix(token.ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.PLUS_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.MINUS_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.MULT_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.DIV_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.MOD_ASSIGN, p.parseAssignExpression)
+
+	p.nextToken()
+	p.nextToken()
 
 	return p
 }
 
 func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
 	exp := &ast.CallExpression{Token: p.cur, Function: function}
-	exp.Arguments = p.parseCallArguments()
+	p.parseCallArguments(exp)
 	return exp
 }
 
-func (p *Parser) parseCallArguments() []ast.Expression {
-	args := []ast.Expression{}
-	if p.peekTokenIs(token.RPAREN) {
+// parseCallArguments parses the positional arguments of a call followed by
+// the arguments passed by name, `name: value`.
+func (p *Parser) parseCallArguments(exp *ast.CallExpression) {
+	exp.Arguments = []ast.Expression{}
+	named := map[string]bool{}
+
+	inGuard := p.inGuard
+	p.inGuard = false
+	defer func() { p.inGuard = inGuard }()
+
+	for !p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+
+		if p.curTokenIs(token.IDENT) && p.peekTokenIs(token.COLON) {
+			arg := &ast.NamedArgument{Name: &ast.Identifier{Token: p.cur, Value: p.cur.Literal}}
+			if named[arg.Name.Value] {
+				msg := fmt.Sprintf("%s: duplicate argument %s", p.cur.Pos, arg.Name.Value)
+				p.errors = append(p.errors, msg)
+			}
+			named[arg.Name.Value] = true
+
+			p.nextToken()
+			p.nextToken()
+			arg.Value = p.parseExpression(token.LOWEST)
+			exp.Named = append(exp.Named, arg)
+		} else {
+			if len(exp.Named) > 0 {
+				msg := fmt.Sprintf("%s: positional argument follows named argument", p.cur.Pos)
+				p.errors = append(p.errors, msg)
+			}
+			exp.Arguments = append(exp.Arguments, p.parseArgument())
+		}
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+
+	if !p.expectToken(token.RPAREN) {
+		exp.Arguments = nil
+	}
+}
+
+// parseExpressionList parses comma-separated expressions up to the closing
+// token end. A trailing comma is allowed so that a list can be split over
+// several lines.
+func (p *Parser) parseExpressionList(end token.Token) []ast.Expression {
+	list := []ast.Expression{}
+	for !p.peekTokenIs(end) {
+		p.nextToken()
+		list = append(list, p.parseElement())
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
 		p.nextToken()
-		return args
 	}
+	if !p.expectToken(end) {
+		return nil
+	}
+	return list
+}
+
+// parseElement parses an element of a list: an expression, or a spread
+// expression expanding into several elements.
+func (p *Parser) parseElement() ast.Expression {
+	if !p.curTokenIs(token.ELLIPSIS) {
+		return p.parseExpression(token.LOWEST)
+	}
+
+	spread := &ast.SpreadExpression{Token: p.cur}
+	p.nextToken()
+	spread.Value = p.parseExpression(token.LOWEST)
+	return spread
+}
+
+// parseMisplacedSpread reports a spread outside of the lists that allow it.
+func (p *Parser) parseMisplacedSpread() ast.Expression {
+	msg := fmt.Sprintf("%s: ... is only allowed in call arguments, array literals and hash literals", p.cur.Pos)
+	p.errors = append(p.errors, msg)
+	return nil
+}
+
+// parseBraced parses the entries of a { } list, separated by commas or
+// newlines, starting at the {. It calls entry at the first token of each
+// entry, which must leave the current token at the entry's last token and
+// report whether the entry was well formed. what names the list and item
+// its entries in error messages.
+func (p *Parser) parseBraced(what, item string, entry func() bool) bool {
+	open := p.cur.Pos
+
+	for p.nextToken(); !p.curTokenIs(token.RCURL); p.nextToken() {
+		switch p.cur.Token {
+		case token.EOF:
+			msg := fmt.Sprintf("%s: expected } to close the %s opened at %s", p.cur.Pos, what, open)
+			p.errors = append(p.errors, msg)
+			return false
+		case token.SEMICOLON:
+			continue
+		}
+
+		if !entry() {
+			return false
+		}
+
+		switch p.peek.Token {
+		case token.COMMA, token.SEMICOLON:
+			p.nextToken()
+		case token.RCURL:
+		default:
+			msg := fmt.Sprintf("%s: expected , or newline after %s, but got %s", p.peek.Pos, item, describe(p.peek))
+			p.errors = append(p.errors, msg)
+			return false
+		}
+	}
+
+	return true
+}
+
+func (p *Parser) parseStringLiteral() ast.Expression {
+	return &ast.StringLiteral{Token: p.cur, Value: p.cur.Literal}
+}
+
+func (p *Parser) parseArrayLiteral() ast.Expression {
+	array := &ast.ArrayLiteral{Token: p.cur}
+	array.Elements = p.parseExpressionList(token.RBRAC)
+	return array
+}
+
+func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
+	exp := &ast.IndexExpression{Token: p.cur, Left: left, Optional: p.curTokenIs(token.OPTIONAL_LBRAC)}
 	p.nextToken()
-	args = append(args, p.parseExpression(token.LOWEST))
-	for p.peekTokenIs(token.COMMA) {
+	exp.Index = p.parseExpression(token.LOWEST)
+	if !p.expectToken(token.RBRAC) {
+		return nil
+	}
+	return exp
+}
+
+func (p *Parser) parseHashLiteral() ast.Expression {
+	hash := &ast.HashLiteral{Token: p.cur, Pairs: []ast.HashPair{}}
+	for !p.peekTokenIs(token.RCURL) {
 		p.nextToken()
+		pair := ast.HashPair{Key: p.parseElement()}
+		if _, spread := pair.Key.(*ast.SpreadExpression); !spread {
+			if !p.expectToken(token.COLON) {
+				return nil
+			}
+			p.nextToken()
+			pair.Value = p.parseExpression(token.LOWEST)
+		}
+		hash.Pairs = append(hash.Pairs, pair)
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
 		p.nextToken()
-		args = append(args, p.parseExpression(token.LOWEST))
 	}
-	if !p.expectToken(token.RPAREN) {
+	if !p.expectToken(token.RCURL) {
 		return nil
 	}
-	return args
+	return hash
 }
 
 func (p *Parser) parseFunctionLiteral() ast.Expression {
@@ -85,34 +289,111 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 		return nil
 	}
 	lit.Parameters = p.parseFunctionParameters()
-	if !p.expectToken(token.LBRAC) {
+	if !p.expectToken(token.LCURL) {
 		return nil
 	}
-	lit.Body = p.parseBlockStatement()
+
+	p.inFunction(func() { lit.Body = p.parseBlockStatement() })
+	markTailCalls(lit.Body)
+
 	return lit
 }
 
-func (p *Parser) parseFunctionParameters() []*ast.Identifier {
-	identifiers := []*ast.Identifier{}
-	if p.peekTokenIs(token.RPAREN) {
-		p.nextToken()
-		return identifiers
-	}
+// inFunction calls parse to parse the body of a function literal, which is
+// outside any loop, since break and continue cannot reach loops outside the
+// function, and outside any try, so its calls can be tail calls.
+func (p *Parser) inFunction(parse func()) {
+	loops, tails := p.loops, p.tails
+	p.loops, p.tails = nil, true
+	defer func() { p.loops, p.tails = loops, tails }()
+
+	parse()
+}
+
+// parseFunctionStatement parses `fn name(params) { body }`.
+func (p *Parser) parseFunctionStatement() *ast.FunctionStatement {
+	stmt := &ast.FunctionStatement{Token: p.cur}
+
 	p.nextToken()
-	ident := &ast.Identifier{Token: p.cur, Value: p.cur.Literal}
-	identifiers = append(identifiers, ident)
-	for p.peekTokenIs(token.COMMA) {
-		p.nextToken()
+	stmt.Name = &ast.Identifier{Token: p.cur, Value: p.cur.Literal}
+
+	lit, ok := p.parseFunctionLiteral().(*ast.FunctionLiteral)
+	if !ok || lit == nil {
+		return nil
+	}
+	lit.Token = stmt.Token
+	lit.Name = stmt.Name.Value
+	stmt.Function = lit
+
+	if !p.endStatement() {
+		return nil
+	}
+
+	return stmt
+}
+
+// parseFunctionParameters parses a parameter list: required parameters,
+// then parameters with default values, then at most one rest parameter.
+func (p *Parser) parseFunctionParameters() []*ast.Parameter {
+	params := []*ast.Parameter{}
+	seen := map[string]bool{}
+
+	for !p.peekTokenIs(token.RPAREN) {
+		param := &ast.Parameter{}
+		if p.peekTokenIs(token.ELLIPSIS) {
+			p.nextToken()
+			param.Rest = true
+			if !p.expectToken(token.IDENT) {
+				return nil
+			}
+		} else {
+			p.nextToken()
+		}
+
+		pos := p.cur.Pos
+		if param.Target = p.parsePattern(); param.Target == nil {
+			return nil
+		}
+		p.parseDefault(&param.Default)
+
+		p.checkDuplicates(ast.PatternNames(param.Target), seen, "parameter")
+		p.checkParameter(pos, param, params)
+		params = append(params, param)
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
 		p.nextToken()
-		ident := &ast.Identifier{Token: p.cur, Value: p.cur.Literal}
-		identifiers = append(identifiers, ident)
 	}
 
 	if !p.expectToken(token.RPAREN) {
 		return nil
 	}
 
-	return identifiers
+	return params
+}
+
+// checkParameter reports a parameter, starting at pos, that cannot follow
+// the parameters before it.
+func (p *Parser) checkParameter(pos token.Position, param *ast.Parameter, before []*ast.Parameter) {
+	var prev *ast.Parameter
+	if len(before) > 0 {
+		prev = before[len(before)-1]
+	}
+
+	var msg string
+	switch {
+	case param.Rest && param.Default != nil:
+		msg = fmt.Sprintf("rest parameter %s cannot have a default value", param.Target)
+	case prev != nil && prev.Rest:
+		msg = fmt.Sprintf("parameter %s follows rest parameter %s, which must be last", param.Target, prev.Target)
+	case param.Default == nil && !param.Rest && prev != nil && prev.Default != nil:
+		msg = fmt.Sprintf("required parameter %s follows parameter %s, which has a default value", param.Target, prev.Target)
+	}
+
+	if msg != "" {
+		p.errors = append(p.errors, fmt.Sprintf("%s: %s", pos, msg))
+	}
 }
 
 func (p *Parser) parseIfExpression() ast.Expression {
@@ -146,25 +427,59 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	block.Statements = []ast.Statement{}
 	p.nextToken()
 	for !p.curTokenIs(token.RCURL) && !p.curTokenIs(token.EOF) {
-		stmt := p.parseStatement()
-		if stmt != nil {
-			block.Statements = append(block.Statements, stmt)
+		if !p.curTokenIs(token.SEMICOLON) {
+			stmt := p.parseStatement()
+			if stmt != nil {
+				block.Statements = append(block.Statements, stmt)
+			}
 		}
 		p.nextToken()
 	}
+	if !p.curTokenIs(token.RCURL) {
+		msg := fmt.Sprintf("%s: expected } to close the block opened at %s", p.cur.Pos, block.Token.Pos)
+		p.errors = append(p.errors, msg)
+	}
 	return block
 }
 
+// parseGroupedExpression parses a parenthesized expression, or the
+// parameters of an arrow function: `(a, b) => a + b`.
 func (p *Parser) parseGroupedExpression() ast.Expression {
-	p.nextToken()
+	if p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		if !p.expectToken(token.ARROW) {
+			return nil
+		}
+		return p.parseArrowFunction(nil)
+	}
 
-	exp := p.parseExpression(token.LOWEST)
+	inGuard := p.inGuard
+	p.inGuard = false
+	var exps []ast.Expression
+	var starts []token.Position
+	for {
+		p.nextToken()
+		starts = append(starts, p.cur.Pos)
+		exps = append(exps, p.parseExpression(token.LOWEST))
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+	p.inGuard = inGuard
 
 	if !p.expectToken(token.RPAREN) {
 		return nil
 	}
 
-	return exp
+	if len(exps) > 1 || p.peekTokenIs(token.ARROW) && !p.inGuard {
+		if !p.expectToken(token.ARROW) {
+			return nil
+		}
+		return p.parseArrowFunction(p.arrowParameters(exps, starts))
+	}
+
+	return exps[0]
 }
 
 func (p *Parser) parseBoolean() ast.Expression {
@@ -178,7 +493,7 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 		Left:     left,
 	}
 
-	precedence := token.LookupPrecedence(p.cur.Literal)
+	precedence := p.curPrecedence()
 
 	p.nextToken()
 
@@ -187,6 +502,70 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	return exp
 }
 
+// parsePipeExpression parses `x |> f(y)` into the call `f(x, y)`. The right
+// side may also be a function without arguments, `x |> f`, which becomes
+// `f(x)`.
+func (p *Parser) parsePipeExpression(left ast.Expression) ast.Expression {
+	pipe := p.cur
+	precedence := p.curPrecedence()
+
+	p.nextToken()
+	right := p.parseExpression(precedence)
+
+	switch right := right.(type) {
+	case *ast.CallExpression:
+		right.Arguments = append([]ast.Expression{left}, right.Arguments...)
+		return right
+	case *ast.Identifier, *ast.MemberExpression, *ast.IndexExpression, *ast.FunctionLiteral:
+		return &ast.CallExpression{Token: pipe, Function: right, Arguments: []ast.Expression{left}}
+	case nil:
+		return nil
+	}
+
+	msg := fmt.Sprintf("%s: cannot pipe into %s, which is not callable", pipe.Pos, right)
+	p.errors = append(p.errors, msg)
+	return nil
+}
+
+// parseAssignExpression parses `target = value` and its compound forms.
+// Assignment is right-associative, so `a = b = 1` assigns 1 to both.
+func (p *Parser) parseAssignExpression(target ast.Expression) ast.Expression {
+	exp := &ast.AssignExpression{
+		Token:    p.cur,
+		Target:   target,
+		Operator: p.cur.Literal,
+	}
+
+	p.nextToken()
+	exp.Value = p.parseExpression(token.ASSIGNMENT - 1)
+
+	switch target := target.(type) {
+	case *ast.Identifier:
+	case *ast.IndexExpression:
+		if target.Optional {
+			return p.invalidTarget(exp)
+		}
+	case *ast.MemberExpression:
+		if target.Optional {
+			return p.invalidTarget(exp)
+		}
+	case nil:
+		return nil
+	default:
+		return p.invalidTarget(exp)
+	}
+
+	return exp
+}
+
+// invalidTarget reports that the target of exp cannot be assigned to, which
+// includes an optional member or index: `a?.b = 1`.
+func (p *Parser) invalidTarget(exp *ast.AssignExpression) ast.Expression {
+	msg := fmt.Sprintf("%s: cannot assign to %s", exp.Token.Pos, exp.Target)
+	p.errors = append(p.errors, msg)
+	return nil
+}
+
 func (p *Parser) parsePrefixExpression() ast.Expression {
 	exp := &ast.PrefixExpression{
 		Token:    p.cur,
@@ -199,18 +578,24 @@ func (p *Parser) parsePrefixExpression() ast.Expression {
 	return exp
 }
 
-func (p *Parser) noPrefixParseFnError(t string) {
-	msg := fmt.Sprintf("no prefix parse function for %s found", t)
+func (p *Parser) noPrefixParseFnError(t token.TokenObj) {
+	msg := fmt.Sprintf("%s: no prefix parse function for %s found", t.Pos, describe(t))
 	p.errors = append(p.errors, msg)
 }
 
+// parseIllegal stands in for a token the lexer rejected; the lexer has
+// already reported why.
+func (p *Parser) parseIllegal() ast.Expression {
+	return nil
+}
+
 func (p *Parser) parseIntegralLiteral() ast.Expression {
 	lit := &ast.IntegralExpression{Token: p.cur}
 
 	value, err := strconv.ParseInt(p.cur.Literal, 0, 64)
 
-	if err == nil {
-		msg := fmt.Sprintf("could not parse %q as integer", p.cur.Literal)
+	if err != nil {
+		msg := fmt.Sprintf("%s: could not parse %q as integer", p.cur.Pos, p.cur.Literal)
 		p.errors = append(p.errors, msg)
 		return nil
 	}
@@ -221,8 +606,17 @@ func (p *Parser) parseIntegralLiteral() ast.Expression {
 }
 
 func (p *Parser) parseIdentifier() ast.Expression {
+	if p.peekTokenIs(token.LCURL) {
+		return p.parseStructLiteral()
+	}
 
-	return &ast.Identifier{Token: p.cur, Value: p.cur.Literal}
+	ident := &ast.Identifier{Token: p.cur, Value: p.cur.Literal}
+	if p.peekTokenIs(token.ARROW) && !p.inGuard {
+		p.nextToken()
+		return p.parseArrowFunction([]*ast.Parameter{{Target: ident}})
+	}
+
+	return ident
 }
 
 func (p *Parser) registerInfix(tok token.Token, fn infixParseFn) {
@@ -235,7 +629,12 @@ func (p *Parser) registerPrefix(tok token.Token, fn prefixParseFn) {
 
 func (p *Parser) nextToken() {
 	p.cur = p.peek
-	p.peek = p.lexer.NextToken()
+
+	peek, err := p.source.Next()
+	if err != nil {
+		p.errors = append(p.errors, fmt.Sprintf("%s: %s", peek.Pos, err))
+	}
+	p.peek = peek
 }
 
 func (p *Parser) ParseProgram() *ast.Program {
@@ -244,10 +643,12 @@ func (p *Parser) ParseProgram() *ast.Program {
 	}
 
 	for p.cur.Token != token.EOF {
-		stmt := p.parseStatement()
+		if !p.curTokenIs(token.SEMICOLON) {
+			stmt := p.parseStatement()
 
-		if stmt != nil {
-			program.Statements = append(program.Statements, stmt)
+			if stmt != nil {
+				program.Statements = append(program.Statements, stmt)
+			}
 		}
 
 		p.nextToken()
@@ -256,15 +657,70 @@ func (p *Parser) ParseProgram() *ast.Program {
 	return program
 }
 
+// parseStatement parses the statement starting at the current token. Every
+// statement, whatever its kind, ends with a semicolon, either written or
+// inserted by the lexer at a line break, which may be left out right before
+// a closing brace or the end of input.
 func (p *Parser) parseStatement() ast.Statement {
-	switch p.cur.Literal {
+	switch p.cur.Token {
 	case token.AT:
-		return p.parseAtStatement()
+		if stmt := p.parseAtStatement(); stmt != nil {
+			return stmt
+		}
 	case token.RET:
-		return p.parseReturnStatement()
+		if stmt := p.parseReturnStatement(); stmt != nil {
+			return stmt
+		}
+	case token.FUNCTION:
+		if p.peekTokenIs(token.IDENT) {
+			if stmt := p.parseFunctionStatement(); stmt != nil {
+				return stmt
+			}
+			return nil
+		}
+		if stmt := p.parseExpressionStatement(); stmt != nil {
+			return stmt
+		}
+	case token.ENUM:
+		if stmt := p.parseEnumStatement(); stmt != nil {
+			return stmt
+		}
+	case token.STRUCT:
+		if stmt := p.parseStructStatement(); stmt != nil {
+			return stmt
+		}
+	case token.IMPL:
+		if stmt := p.parseImplStatement(); stmt != nil {
+			return stmt
+		}
+	case token.THROW:
+		if stmt := p.parseThrowStatement(); stmt != nil {
+			return stmt
+		}
+	case token.DEFER:
+		if stmt := p.parseDeferStatement(); stmt != nil {
+			return stmt
+		}
+	case token.WHILE, token.FOR:
+		return p.parseLoop(nil)
+	case token.BREAK, token.CONTINUE:
+		if stmt := p.parseBranchStatement(); stmt != nil {
+			return stmt
+		}
+	case token.IDENT:
+		if p.peekTokenIs(token.COLON) {
+			return p.parseLabeledStatement()
+		}
+		if stmt := p.parseExpressionStatement(); stmt != nil {
+			return stmt
+		}
 	default:
-		return p.parseExpressionStatement()
+		if stmt := p.parseExpressionStatement(); stmt != nil {
+			return stmt
+		}
 	}
+
+	return nil
 }
 
 func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
@@ -272,25 +728,50 @@ func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 
 	stmt.Expression = p.parseExpression(token.LOWEST)
 
+	if !p.endStatement() {
+		return nil
+	}
+
+	return stmt
+}
+
+// endStatement checks that the current token ends a statement and consumes
+// the semicolon after it, if any. Otherwise it reports an error and skips
+// ahead to the end of the statement so that one mistake yields one error.
+func (p *Parser) endStatement() bool {
+	switch p.peek.Token {
+	case token.SEMICOLON:
+		p.nextToken()
+		return true
+	case token.RCURL, token.EOF:
+		return true
+	}
+
+	msg := fmt.Sprintf("%s: expected ; or newline after statement, but got %s", p.peek.Pos, describe(p.peek))
+	p.errors = append(p.errors, msg)
+
+	for !p.peekTokenIs(token.SEMICOLON) && !p.peekTokenIs(token.RCURL) && !p.peekTokenIs(token.EOF) {
+		p.nextToken()
+	}
 	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
 
-	return stmt
+	return false
 }
 
 func (p *Parser) parseExpression(precedence int) ast.Expression {
 	prefix := p.prefixFn[p.cur.Token]
 
 	if prefix == nil {
-		p.noPrefixParseFnError(p.cur.Literal)
+		p.noPrefixParseFnError(p.cur)
 		return nil
 	}
 
 	leftExp := prefix()
 
-	for p.cur.Token != token.SEMICOLON && precedence < token.LookupPrecedence(p.peek.Literal) {
-		infix := p.infixFn[p.cur.Token]
+	for precedence < p.peekPrecedence() {
+		infix := p.infixFn[p.peek.Token]
 
 		if infix == nil {
 			return leftExp
@@ -305,43 +786,50 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 }
 
 func (p *Parser) parseAtStatement() *ast.AtStatement {
-	stmt := &ast.AtStatement{}
+	stmt := &ast.AtStatement{Token: p.cur}
 
-	// token
-	// identifier
-	// value
-	stmt.Token = p.cur
+	if p.peekTokenIs(token.MUT) {
+		p.nextToken()
+		stmt.Mutable = true
+	}
 
-	if !p.expectToken(token.IDENT) {
+	p.nextToken()
+	if stmt.Target = p.parsePattern(); stmt.Target == nil {
 		return nil
 	}
+	p.checkDuplicates(ast.PatternNames(stmt.Target), map[string]bool{}, "binding")
 
 	if !p.expectToken(token.ASSIGN) {
 		return nil
 	}
-	stmt.Identifier = &ast.Identifier{
-		Token: p.cur,
-		Value: p.cur.Literal,
-	}
+	p.nextToken()
 
 	stmt.Value = p.parseExpression(token.LOWEST)
 
-	if p.curTokenIs(token.SEMICOLON) {
-		p.nextToken()
+	if !p.endStatement() {
+		return nil
 	}
 
 	return stmt
 }
 
-func (p *Parser) peekTokenIs(t string) bool {
-	return p.peek.Literal == t
+func (p *Parser) peekTokenIs(t token.Token) bool {
+	return p.peek.Token == t
 }
 
-func (p *Parser) curTokenIs(t string) bool {
-	return p.cur.Literal == t
+func (p *Parser) curTokenIs(t token.Token) bool {
+	return p.cur.Token == t
 }
 
-func (p *Parser) expectToken(t string) bool {
+func (p *Parser) peekPrecedence() int {
+	return token.LookupPrecedence(string(p.peek.Token))
+}
+
+func (p *Parser) curPrecedence() int {
+	return token.LookupPrecedence(string(p.cur.Token))
+}
+
+func (p *Parser) expectToken(t token.Token) bool {
 	if p.peekTokenIs(t) {
 		p.nextToken()
 		return true
@@ -351,26 +839,189 @@ func (p *Parser) expectToken(t string) bool {
 	return false
 }
 
+// Errors returns the errors reported by the token source followed by those
+// found while parsing.
 func (p *Parser) Errors() []string {
+	if source, ok := p.source.(errorSource); ok && len(source.Errors()) > 0 {
+		return append(append([]string{}, source.Errors()...), p.errors...)
+	}
+
 	return p.errors
 }
 
-func (p *Parser) peekError(t string) {
-	msg := fmt.Sprintf("expected next token to be %s, but got %s", t, p.peek.Token)
+func (p *Parser) peekError(t token.Token) {
+	msg := fmt.Sprintf("%s: expected next token to be %s, but got %s", p.peek.Pos, t, describe(p.peek))
 
 	p.errors = append(p.errors, msg)
 }
 
+// describe names a token for an error message, calling out semicolons the
+// lexer inserted so that users are not told about ones they did not write.
+func describe(t token.TokenObj) string {
+	if t.Token == token.SEMICOLON && t.Literal == "\n" {
+		return "newline"
+	}
+
+	return string(t.Token)
+}
+
 func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 	stmt := &ast.ReturnStatement{Token: p.cur}
 
+	if !p.peekTokenIs(token.SEMICOLON) && !p.peekTokenIs(token.RCURL) && !p.peekTokenIs(token.EOF) {
+		p.nextToken()
+		stmt.ReturnValue = p.parseExpression(token.LOWEST)
+		if p.tails {
+			markTail(stmt.ReturnValue)
+		}
+	}
+
+	if !p.endStatement() {
+		return nil
+	}
+
+	return stmt
+}
+
+// parseLabeledStatement parses `label: loop`. Only loops may be labelled.
+func (p *Parser) parseLabeledStatement() ast.Statement {
+	label := &ast.Identifier{Token: p.cur, Value: p.cur.Literal}
 	p.nextToken()
 
-	stmt.ReturnValue = p.parseExpression(token.LOWEST)
+	if !p.peekTokenIs(token.WHILE) && !p.peekTokenIs(token.FOR) {
+		msg := fmt.Sprintf("%s: label %s must be followed by a loop, but got %s", p.peek.Pos, label.Value, describe(p.peek))
+		p.errors = append(p.errors, msg)
+		return nil
+	}
 
-	if p.curTokenIs(token.SEMICOLON) {
+	for _, l := range p.loops {
+		if l == label.Value {
+			msg := fmt.Sprintf("%s: label %s already defined by an enclosing loop", label.Token.Pos, label.Value)
+			p.errors = append(p.errors, msg)
+		}
+	}
+
+	p.nextToken()
+	return p.parseLoop(label)
+}
+
+func (p *Parser) parseLoop(label *ast.Identifier) ast.Statement {
+	var stmt ast.Statement
+
+	switch p.cur.Token {
+	case token.WHILE:
+		if while := p.parseWhileStatement(label); while != nil {
+			stmt = while
+		}
+	case token.FOR:
+		if loop := p.parseForStatement(label); loop != nil {
+			stmt = loop
+		}
+	}
+
+	if stmt == nil || !p.endStatement() {
+		return nil
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseWhileStatement(label *ast.Identifier) *ast.WhileStatement {
+	stmt := &ast.WhileStatement{Token: p.cur, Label: label}
+
+	if !p.expectToken(token.LPAREN) {
+		return nil
+	}
+	p.nextToken()
+	stmt.Condition = p.parseExpression(token.LOWEST)
+	if !p.expectToken(token.RPAREN) {
+		return nil
+	}
+	if !p.expectToken(token.LCURL) {
+		return nil
+	}
+	stmt.Body = p.parseLoopBody(label)
+
+	return stmt
+}
+
+func (p *Parser) parseForStatement(label *ast.Identifier) *ast.ForStatement {
+	stmt := &ast.ForStatement{Token: p.cur, Label: label}
+
+	if !p.expectToken(token.LPAREN) || !p.expectToken(token.IDENT) {
+		return nil
+	}
+	stmt.Value = &ast.Identifier{Token: p.cur, Value: p.cur.Literal}
+
+	if p.peekTokenIs(token.COMMA) {
 		p.nextToken()
+		if !p.expectToken(token.IDENT) {
+			return nil
+		}
+		stmt.Key = stmt.Value
+		stmt.Value = &ast.Identifier{Token: p.cur, Value: p.cur.Literal}
 	}
 
+	if !p.expectToken(token.IN) {
+		return nil
+	}
+	p.nextToken()
+	stmt.Iterable = p.parseExpression(token.LOWEST)
+	if !p.expectToken(token.RPAREN) {
+		return nil
+	}
+	if !p.expectToken(token.LCURL) {
+		return nil
+	}
+	stmt.Body = p.parseLoopBody(label)
+
 	return stmt
 }
+
+func (p *Parser) parseLoopBody(label *ast.Identifier) *ast.BlockStatement {
+	name := ""
+	if label != nil {
+		name = label.Value
+	}
+
+	p.loops = append(p.loops, name)
+	body := p.parseBlockStatement()
+	p.loops = p.loops[:len(p.loops)-1]
+
+	return body
+}
+
+// parseBranchStatement parses break and continue, checking that they appear
+// inside a loop and that any label they name belongs to an enclosing loop.
+func (p *Parser) parseBranchStatement() *ast.BranchStatement {
+	stmt := &ast.BranchStatement{Token: p.cur}
+
+	if p.peekTokenIs(token.IDENT) {
+		p.nextToken()
+		stmt.Label = &ast.Identifier{Token: p.cur, Value: p.cur.Literal}
+	}
+
+	if len(p.loops) == 0 {
+		msg := fmt.Sprintf("%s: %s is not in a loop", stmt.Token.Pos, stmt.Token.Literal)
+		p.errors = append(p.errors, msg)
+	} else if stmt.Label != nil && !p.inLoop(stmt.Label.Value) {
+		msg := fmt.Sprintf("%s: %s label not defined: %s", stmt.Label.Token.Pos, stmt.Token.Literal, stmt.Label.Value)
+		p.errors = append(p.errors, msg)
+	}
+
+	if !p.endStatement() {
+		return nil
+	}
+
+	return stmt
+}
+
+func (p *Parser) inLoop(label string) bool {
+	for _, l := range p.loops {
+		if l == label {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,671 @@
+package parser
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/0xedb/intlang/ast"
+	"github.com/0xedb/intlang/lexer"
+)
+
+func TestParseFromReader(t *testing.T) {
+	const statements = 100000
+	input := strings.Repeat("0x2a;\n", statements)
+
+	p := New(lexer.NewReader(strings.NewReader(input)))
+	program := p.ParseProgram()
+
+	if errs := p.Errors(); len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if len(program.Statements) != statements {
+		t.Fatalf("Wanted: %d statements, Got: %d", statements, len(program.Statements))
+	}
+
+	stmt := program.Statements[statements-1].(*ast.ExpressionStatement)
+	if lit := stmt.Expression.(*ast.IntegralExpression); lit.Value != 42 {
+		t.Fatalf("Wanted: 42, Got: %d", lit.Value)
+	}
+}
+
+func TestParseReadError(t *testing.T) {
+	src := io.MultiReader(strings.NewReader("1;\n2;"), iotest.ErrReader(errors.New("boom")))
+
+	p := New(lexer.NewReader(src))
+	p.ParseProgram()
+
+	if errs := p.Errors(); len(errs) != 1 || errs[0] != "2:3: boom" {
+		t.Fatalf("Wanted: [2:3: boom], Got: %v", errs)
+	}
+}
+
+func TestLexerErrors(t *testing.T) {
+	p := New(lexer.New("0x;"))
+	p.ParseProgram()
+
+	if errs := p.Errors(); len(errs) == 0 || errs[0] != "1:1: hexadecimal literal has no digits" {
+		t.Fatalf("Wanted lexer error first, Got: %v", errs)
+	}
+}
+
+func parse(t *testing.T, input string) *ast.Program {
+	t.Helper()
+
+	p := New(lexer.New(input))
+	program := p.ParseProgram()
+
+	if errs := p.Errors(); len(errs) != 0 {
+		t.Fatalf("unexpected errors for %q: %v", input, errs)
+	}
+
+	return program
+}
+
+func TestOperatorPrecedence(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect string
+	}{
+		{"-a * b", "((-a) * b)"},
+		{"!-a", "(!(-a))"},
+		{"a + b - c", "((a + b) - c)"},
+		{"a + b * c - d / e", "((a + (b * c)) - (d / e))"},
+		{"5 > 4 == 3 < 4", "((5 > 4) == (3 < 4))"},
+		{"3 + 4 * 5 != 3 * 1 + 4 * 5", "((3 + (4 * 5)) != ((3 * 1) + (4 * 5)))"},
+		{"(1 + 2) * 3", "((1 + 2) * 3)"},
+		{"a + add(b * c) + d", "((a + add((b * c))) + d)"},
+		{"add(a, b, 1, 2 * 3, add(6, 7 * 8))", "add(a, b, 1, (2 * 3), add(6, (7 * 8)))"},
+		{"fn(x, y) { x + y }(1, 2)", "fn(x, y) { (x + y) }(1, 2)"},
+		{"xs |> map(f) |> filter(g) |> sum()", "sum(filter(map(xs, f), g))"},
+		{"a + 1 |> f(x: 2)", "f((a + 1), x: 2)"},
+		{"x = a ?? b |> f", "(x = f((a ?? b)))"},
+		{"x |> p.m |> fns[0] |> fn(y) { y }", "fn(y) { y }((fns[0])((p.m)(x)))"},
+		{"x |> f?.()", "f?.(x)"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			if got := parse(t, test.input).String(); got != test.expect {
+				t.Fatalf("Wanted: %s, Got: %s", test.expect, got)
+			}
+		})
+	}
+}
+
+func TestStatementTermination(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		expect []string
+	}{
+		{"semicolons", "@ x = 1; x; ret x;", []string{"@ x = 1;", "x", "ret x;"}},
+		{"newlines", "@ x = 1\nx\nret x\n", []string{"@ x = 1;", "x", "ret x;"}},
+		{"no final terminator", "@ x = 1\nx", []string{"@ x = 1;", "x"}},
+		{"bare ret", "ret\nx", []string{"ret;", "x"}},
+		{"empty statements", ";;x;;\n\n;", []string{"x"}},
+		{"operator continues line", "@ x = 1 +\n\t2\n", []string{"@ x = (1 + 2);"}},
+		{"trailing comma", "add(\n\t1,\n\t2,\n)", []string{"add(1, 2)"}},
+		{"block", "@ f = fn(a,\n b) {\n\t@ c = a\n\tret c + b\n}\nf(1, 2)", []string{"@ f = fn(a, b) { @ c = a; ret (c + b) };", "f(1, 2)"}},
+		{"one line block", "if (x) { 1 } el { ret 2 }", []string{"if (x) { 1 } el { ret 2 }"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			program := parse(t, test.input)
+
+			if len(program.Statements) != len(test.expect) {
+				t.Fatalf("Wanted: %d statements, Got: %d (%s)", len(test.expect), len(program.Statements), program)
+			}
+			for i, stmt := range program.Statements {
+				if got := stmt.String(); got != test.expect[i] {
+					t.Fatalf("statement %d: Wanted: %s, Got: %s", i, test.expect[i], got)
+				}
+			}
+		})
+	}
+}
+
+func TestStatementTerminationErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		expect []string
+	}{
+		{"two expressions", "x y\nz", []string{"1:3: expected ; or newline after statement, but got IDENT"}},
+		{"two declarations", "@ x = 1 @ y = 2", []string{"1:9: expected ; or newline after statement, but got @"}},
+		{"ret with trailing junk", "ret 1 2;", []string{"1:7: expected ; or newline after statement, but got INT"}},
+		{"missing comma", "add(1\n, 2)", []string{"1:6: expected next token to be ), but got newline"}},
+		{"unclosed block", "if (x) { 1", []string{"1:11: expected } to close the block opened at 1:8"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := New(lexer.New(test.input))
+			p.ParseProgram()
+
+			errs := p.Errors()
+			if len(errs) < len(test.expect) {
+				t.Fatalf("Wanted: %v, Got: %v", test.expect, errs)
+			}
+			for i, msg := range test.expect {
+				if errs[i] != msg {
+					t.Fatalf("Wanted: %v, Got: %v", test.expect, errs)
+				}
+			}
+		})
+	}
+}
+
+func TestLoops(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect string
+	}{
+		{"while (i < 10) { i }", "while ((i < 10)) { i }"},
+		{"for (x in xs) { x }", "for (x in xs) { x }"},
+		{"for (k, v in {\"a\": [1][0]}) { k }", "for (k, v in {\"a\": ([1][0])}) { k }"},
+		{"for (i in 0..n + 1) { break }", "for (i in (0 .. (n + 1))) { break }"},
+		{"outer: while (true) { for (x in y) { continue outer } }", "outer: while (true) { for (x in y) { continue outer } }"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			if got := parse(t, test.input).String(); got != test.expect {
+				t.Fatalf("Wanted: %s, Got: %s", test.expect, got)
+			}
+		})
+	}
+}
+
+func TestLoopErrors(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect string
+	}{
+		{"break", "1:1: break is not in a loop"},
+		{"while (true) { fn() { continue } }", "1:23: continue is not in a loop"},
+		{"while (true) { break outer }", "1:22: break label not defined: outer"},
+		{"outer: x", "1:8: label outer must be followed by a loop, but got IDENT"},
+		{"a: while (true) { a: while (true) { } }", "1:19: label a already defined by an enclosing loop"},
+		{"for (x y) { }", "1:8: expected next token to be in, but got IDENT"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			p := New(lexer.New(test.input))
+			p.ParseProgram()
+
+			if errs := p.Errors(); len(errs) == 0 || errs[0] != test.expect {
+				t.Fatalf("Wanted: %s, Got: %v", test.expect, errs)
+			}
+		})
+	}
+}
+
+func TestAssignExpressions(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect string
+	}{
+		{"x = 1 + 2", "(x = (1 + 2))"},
+		{"a = b = c", "(a = (b = c))"},
+		{"x += y * 2", "(x += (y * 2))"},
+		{"x %= 3 % 2", "(x %= (3 % 2))"},
+		{"xs[i + 1] -= 1", "((xs[(i + 1)]) -= 1)"},
+		{"a == b", "(a == b)"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			if got := parse(t, test.input).String(); got != test.expect {
+				t.Fatalf("Wanted: %s, Got: %s", test.expect, got)
+			}
+		})
+	}
+
+	p := New(lexer.New("1 = 2"))
+	p.ParseProgram()
+	if errs := p.Errors(); len(errs) != 1 || errs[0] != "1:3: cannot assign to 1" {
+		t.Fatalf("Wanted: [1:3: cannot assign to 1], Got: %v", errs)
+	}
+}
+
+func TestFunctionStatements(t *testing.T) {
+	program := parse(t, "fn add(a, b) { a + b }\nfn(x) { x }(1)")
+
+	if len(program.Statements) != 2 {
+		t.Fatalf("Wanted: 2 statements, Got: %d", len(program.Statements))
+	}
+
+	decl, ok := program.Statements[0].(*ast.FunctionStatement)
+	if !ok {
+		t.Fatalf("Wanted: *ast.FunctionStatement, Got: %T", program.Statements[0])
+	}
+	if decl.Name.Value != "add" || decl.Function.Name != "add" || len(decl.Function.Parameters) != 2 {
+		t.Fatalf("unexpected declaration: %s", decl)
+	}
+	if got := decl.String(); got != "fn add(a, b) { (a + b) }" {
+		t.Fatalf("Wanted: fn add(a, b) { (a + b) }, Got: %s", got)
+	}
+
+	if _, ok := program.Statements[1].(*ast.ExpressionStatement); !ok {
+		t.Fatalf("Wanted: *ast.ExpressionStatement, Got: %T", program.Statements[1])
+	}
+}
+
+func TestCallArgumentsAndParameters(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect string
+	}{
+		{"fn(x, y = 10) { x }", "fn(x, y = 10) { x }"},
+		{"fn(head, ...tail) { tail }", "fn(head, ...tail) { tail }"},
+		{"fn(x = a == 1) { x }", "fn(x = (a == 1)) { x }"},
+		{"f(x: 1, y: 2 + 3)", "f(x: 1, y: (2 + 3))"},
+		{"f(1, y: 2,)", "f(1, y: 2)"},
+		{"f(...xs, ...a..b)", "f(...xs, ...(a .. b))"},
+		{"[0, ...xs + ys]", "[0, ...(xs + ys)]"},
+		{`{...defaults, "k": 1}`, `{...defaults, "k": 1}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			if got := parse(t, test.input).String(); got != test.expect {
+				t.Fatalf("Wanted: %s, Got: %s", test.expect, got)
+			}
+		})
+	}
+}
+
+func TestCallArgumentsAndParametersErrors(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect string
+	}{
+		{"fn(x, x) { }", "1:7: duplicate parameter x"},
+		{"fn(...xs, y) { }", "1:11: parameter y follows rest parameter xs, which must be last"},
+		{"fn(...xs = []) { }", "1:7: rest parameter xs cannot have a default value"},
+		{"fn(x = 1, y) { }", "1:11: required parameter y follows parameter x, which has a default value"},
+		{"f(x: 1, 2)", "1:9: positional argument follows named argument"},
+		{"f(x: 1, x: 2)", "1:9: duplicate argument x"},
+		{"@ x = ...xs", "1:7: ... is only allowed in call arguments, array literals and hash literals"},
+		{"[(...xs)]", "1:3: ... is only allowed in call arguments, array literals and hash literals"},
+		{"x |> 1", "1:3: cannot pipe into 1, which is not callable"},
+		{"x |> f() + 1", "1:3: cannot pipe into (f() + 1), which is not callable"},
+		{"x |>", "1:5: no prefix parse function for EOF found"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			p := New(lexer.New(test.input))
+			p.ParseProgram()
+
+			if errs := p.Errors(); len(errs) == 0 || errs[0] != test.expect {
+				t.Fatalf("Wanted: %s, Got: %v", test.expect, errs)
+			}
+		})
+	}
+}
+
+func TestPatterns(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect string
+	}{
+		{"@ [a, b, ...rest] = xs", "@ [a, b, ...rest] = xs;"},
+		{"@ {name, age: years} = person", "@ {name, age: years} = person;"},
+		{"@ mut [a, b = 2,] = xs", "@ mut [a, b = 2] = xs;"},
+		{"@ {pos: [x, y], tags = []} = p", "@ {pos: [x, y], tags = []} = p;"},
+		{"@ [] = xs", "@ [] = xs;"},
+		{"fn([a, b], {c} = h, ...rest) { a }", "fn([a, b], {c} = h, ...rest) { a }"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			if got := parse(t, test.input).String(); got != test.expect {
+				t.Fatalf("Wanted: %s, Got: %s", test.expect, got)
+			}
+		})
+	}
+}
+
+func TestPatternErrors(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect string
+	}{
+		{"@ + = x", "1:3: expected a pattern, but got +"},
+		{"@ [a, ...b, c] = x", "1:10: rest element ...b must be last"},
+		{"@ {1: a} = x", "1:4: expected next token to be IDENT, but got INT"},
+		{"@ [a, {b: a}] = x", "1:11: duplicate binding a"},
+		{"fn([a, b], {a}) { }", "1:13: duplicate parameter a"},
+		{"fn([a] = [1], b) { }", "1:15: required parameter b follows parameter [a], which has a default value"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			p := New(lexer.New(test.input))
+			p.ParseProgram()
+
+			if errs := p.Errors(); len(errs) == 0 || errs[0] != test.expect {
+				t.Fatalf("Wanted: %s, Got: %v", test.expect, errs)
+			}
+		})
+	}
+}
+
+func TestMatchExpressions(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect string
+	}{
+		{"match (x) { 1 => a, _ => b }", "match (x) { 1 => a, _ => b }"},
+		{"match (x) {\n-1 | 0 => \"small\"\nn if n > 9 => n,\n}", `match (x) { -1 | 0 => "small", n if (n > 9) => n }`},
+		{"match (p) { [0, y] => y, {kind: \"c\", r} => r }", `match (p) { [0, y] => y, {kind: "c", r} => r }`},
+		{"match (x) { true => { @ y = 1\ny } }", "match (x) { true => { @ y = 1; y } }"},
+		{"match (x) { }", "match (x) {  }"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			if got := parse(t, test.input).String(); got != test.expect {
+				t.Fatalf("Wanted: %s, Got: %s", test.expect, got)
+			}
+		})
+	}
+}
+
+func TestMatchErrors(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect string
+	}{
+		{"match (x) { 1 2 }", "1:15: expected next token to be =>, but got INT"},
+		{"match (x) { 1 => a b => c }", "1:20: expected , or newline after match arm, but got IDENT"},
+		{"match (x) { [a] | [b] => 1 }", "1:13: alternatives of [a] | [b] bind different names: [a] and [b]"},
+		{"match (x) { [a, a] => 1 }", "1:17: duplicate binding a"},
+		{"match (x) { 1 => a", "1:19: expected } to close the match opened at 1:11"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			p := New(lexer.New(test.input))
+			p.ParseProgram()
+
+			if errs := p.Errors(); len(errs) == 0 || errs[0] != test.expect {
+				t.Fatalf("Wanted: %s, Got: %v", test.expect, errs)
+			}
+		})
+	}
+}
+
+func TestEnumStatements(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect string
+	}{
+		{"enum Shape { Circle(r), Rect(w, h) }", "enum Shape { Circle(r), Rect(w, h) }"},
+		{"enum Option {\nSome(value)\nNone,\n}", "enum Option { Some(value), None }"},
+		{"@ Some(x) = opt", "@ Some(x) = opt;"},
+		{"match (o) { Some(0) | None => 0, Some(n) => n }", "match (o) { Some(0) | None => 0, Some(n) => n }"},
+		{"match (o) { Pair([a], _) => a }", "match (o) { Pair([a], _) => a }"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			if got := parse(t, test.input).String(); got != test.expect {
+				t.Fatalf("Wanted: %s, Got: %s", test.expect, got)
+			}
+		})
+	}
+
+	p := New(lexer.New("match (o) { None => 0, none => 1 }"))
+	arms := p.ParseProgram().Statements[0].(*ast.ExpressionStatement).Expression.(*ast.MatchExpression).Arms
+	if _, ok := arms[0].Pattern.(*ast.VariantPattern); !ok {
+		t.Fatalf("Wanted None to be a variant pattern, Got: %T", arms[0].Pattern)
+	}
+	if _, ok := arms[1].Pattern.(*ast.Identifier); !ok {
+		t.Fatalf("Wanted none to be a binding, Got: %T", arms[1].Pattern)
+	}
+}
+
+func TestEnumErrors(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect string
+	}{
+		{"enum Shape { circle(r) }", "1:14: variant circle of enum Shape must start with an upper-case letter"},
+		{"enum Shape { A, A }", "1:17: duplicate variant A in enum Shape"},
+		{"enum Shape { A(x, x) }", "1:19: duplicate field x"},
+		{"enum Shape { A B }", "1:16: expected , or newline after enum variant, but got IDENT"},
+		{"enum Shape { 1 }", "1:14: expected an enum variant, but got INT"},
+		{"enum { A }", "1:6: expected next token to be IDENT, but got {"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			p := New(lexer.New(test.input))
+			p.ParseProgram()
+
+			if errs := p.Errors(); len(errs) == 0 || errs[0] != test.expect {
+				t.Fatalf("Wanted: %s, Got: %v", test.expect, errs)
+			}
+		})
+	}
+}
+
+func TestStructs(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect string
+	}{
+		{"struct Point { x, y }", "struct Point { x, y }"},
+		{"struct Point {\nx\ny\n}", "struct Point { x, y }"},
+		{"Point { x: 1, y: 2 + 3 }", "Point { x: 1, y: (2 + 3) }"},
+		{"Point {\nx: 1,\ny: 2\n}.x", "(Point { x: 1, y: 2 }.x)"},
+		{"a.b.c", "((a.b).c)"},
+		{"-p.x * 2", "((-(p.x)) * 2)"},
+		{"a.b(c)[0]", "((a.b)(c)[0])"},
+		{"xs[0].y", "((xs[0]).y)"},
+		{"p.x += 1", "((p.x) += 1)"},
+		{"a?.b?[0]?.(x)", "((a?.b)?[0])?.(x)"},
+		{"a?.b.c?.d(1)", "(((a?.b).c)?.d)(1)"},
+		{"f()?.x", "(f()?.x)"},
+		{"(f()?).x", "((f()?).x)"},
+		{"a ?? b ?? c", "((a ?? b) ?? c)"},
+		{"a ?? b == c", "(a ?? (b == c))"},
+		{"x = a?.b ?? 1 + 2", "(x = ((a?.b) ?? (1 + 2)))"},
+		{"impl Point { fn norm(self) { self.x }; fn origin() { 0 } }", "impl Point { fn norm(self) { (self.x) }; fn origin() { 0 } }"},
+		{"impl Point {\nfn norm(self) {\nself.x\n}\n}", "impl Point { fn norm(self) { (self.x) } }"},
+		{"impl Point { }", "impl Point {  }"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			if got := parse(t, test.input).String(); got != test.expect {
+				t.Fatalf("Wanted: %s, Got: %s", test.expect, got)
+			}
+		})
+	}
+}
+
+func TestStructErrors(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect string
+	}{
+		{"struct Point { x, x }", "1:19: duplicate field x"},
+		{"struct Point { 1 }", "1:16: expected a field name, but got INT"},
+		{"struct Point { x y }", "1:18: expected , or newline after field, but got IDENT"},
+		{"struct Point { x, y }\nPoint { x: 1, z: 2 }", "2:15: struct Point has no field z"},
+		{"struct Point { x, y }\nPoint { x: 1 }", "2:1: missing field y in Point literal"},
+		{"Point { x: 1, x: 2 }", "1:15: duplicate field x"},
+		{"p.1", "1:3: expected next token to be IDENT, but got INT"},
+		{"impl Point { fn f() { }; fn f(self) { } }", "1:29: duplicate method f"},
+		{"impl Point { x }", "1:14: expected a method declaration, but got IDENT"},
+		{"impl Point { fn() { } }", "1:14: expected a method declaration, but got fn"},
+		{"impl { }", "1:6: expected next token to be IDENT, but got {"},
+		{"a?.b = 1", "1:6: cannot assign to (a?.b)"},
+		{"a?[0] += 1", "1:7: cannot assign to (a?[0])"},
+		{"a?.1", "1:4: expected next token to be IDENT, but got INT"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			p := New(lexer.New(test.input))
+			p.ParseProgram()
+
+			if errs := p.Errors(); len(errs) == 0 || errs[0] != test.expect {
+				t.Fatalf("Wanted: %s, Got: %v", test.expect, errs)
+			}
+		})
+	}
+}
+
+func TestTryExpressions(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect string
+	}{
+		{"throw x + 1", "throw (x + 1);"},
+		{"try { f() } catch (e) { g(e) }", "try { f() } catch (e) { g(e) }"},
+		{"try { f() } catch { 0 }", "try { f() } catch { 0 }"},
+		{"try { f() } finally { g() }", "try { f() } finally { g() }"},
+		{"@ x = try { f() } catch (e) { 0 } finally { g() }", "@ x = try { f() } catch (e) { 0 } finally { g() };"},
+		{"@ x = f()?", "@ x = (f()?);"},
+		{"-a.b[0]?", "(-(((a.b)[0])?))"},
+		{"f(x)? + g()?", "((f(x)?) + (g()?))"},
+		{"f()?\ng()", "(f()?)g()"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			if got := parse(t, test.input).String(); got != test.expect {
+				t.Fatalf("Wanted: %s, Got: %s", test.expect, got)
+			}
+		})
+	}
+}
+
+func TestTryErrors(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect string
+	}{
+		{"throw", "1:1: throw needs a value to throw"},
+		{"fn f() { throw }", "1:10: throw needs a value to throw"},
+		{"try { f() }", "1:12: try at 1:1 needs a catch or finally clause, but got newline"},
+		{"try { f() }\ncatch (e) { }", "1:12: try at 1:1 needs a catch or finally clause, but got newline"},
+		{"try { f() } catch (1) { }", "1:20: expected next token to be IDENT, but got INT"},
+		{"try { f() } catch e { }", "1:19: expected next token to be {, but got IDENT"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			p := New(lexer.New(test.input))
+			p.ParseProgram()
+
+			if errs := p.Errors(); len(errs) == 0 || errs[0] != test.expect {
+				t.Fatalf("Wanted: %s, Got: %v", test.expect, errs)
+			}
+		})
+	}
+}
+
+func TestDeferStatements(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect string
+	}{
+		{"defer f(x)", "defer f(x);"},
+		{"defer p.close()", "defer (p.close)();"},
+		{"defer fn() { x }()", "defer fn() { x }();"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			if got := parse(t, test.input).String(); got != test.expect {
+				t.Fatalf("Wanted: %s, Got: %s", test.expect, got)
+			}
+		})
+	}
+
+	errors := []struct {
+		input  string
+		expect string
+	}{
+		{"defer x", "1:7: defer needs a function call, but got x"},
+		{"defer -f()", "1:7: defer needs a function call, but got (-f())"},
+	}
+
+	for _, test := range errors {
+		t.Run(test.input, func(t *testing.T) {
+			p := New(lexer.New(test.input))
+			p.ParseProgram()
+
+			if errs := p.Errors(); len(errs) == 0 || errs[0] != test.expect {
+				t.Fatalf("Wanted: %s, Got: %v", test.expect, errs)
+			}
+		})
+	}
+}
+
+func TestArrowFunctions(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect string
+	}{
+		{"x => x * 2", "fn(x) { ret (x * 2) }"},
+		{"(a, b) => a + b", "fn(a, b) { ret (a + b) }"},
+		{"() => 1", "fn() { ret 1 }"},
+		{"(x) => (x)", "fn(x) { ret x }"},
+		{"(a, b = 2) => a", "fn(a, b = 2) { ret a }"},
+		{"n => x => x + n", "fn(n) { ret fn(x) { ret (x + n) } }"},
+		{"map(xs, x => x, 1)", "map(xs, fn(x) { ret x }, 1)"},
+		{"@ f = x => x |> g", "@ f = fn(x) { ret g(x) };"},
+		{"(x)", "x"},
+		{"add(1, _)", "add(1, _)"},
+		{"f(_, y: _x)", "f(_, y: _x)"},
+		{"xs |> map(_, 1)", "map(xs, _, 1)"},
+		{"match (x) { n if ok => n }", "match (x) { n if ok => n }"},
+		{"match (x) { n if (ok) => n }", "match (x) { n if ok => n }"},
+		{"match (x) { n if any(n, y => y) => n }", "match (x) { n if any(n, fn(y) { ret y }) => n }"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			if got := parse(t, test.input).String(); got != test.expect {
+				t.Fatalf("Wanted: %s, Got: %s", test.expect, got)
+			}
+		})
+	}
+}
+
+func TestArrowFunctionErrors(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect string
+	}{
+		{"(a, a) => a", "1:5: duplicate parameter a"},
+		{"(a, 1) => a", "1:5: expected a parameter, but got 1"},
+		{"(a = 1, b) => a", "1:9: required parameter b follows parameter a, which has a default value"},
+		{"(a, b)", "1:7: expected next token to be =>, but got newline"},
+		{"() + 1", "1:4: expected next token to be =>, but got +"},
+		{"while (true) { x => if (x) { break } }", "1:30: break is not in a loop"},
+		{"defer f(1, _)", "1:7: cannot defer f(1, _), which leaves out arguments"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			p := New(lexer.New(test.input))
+			p.ParseProgram()
+
+			if errs := p.Errors(); len(errs) == 0 || errs[0] != test.expect {
+				t.Fatalf("Wanted: %s, Got: %v", test.expect, errs)
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/0xedb/intlang/ast"
+	"github.com/0xedb/intlang/token"
+)
+
+// parsePattern parses a pattern starting at the current token: one
+// alternative, or several separated by |.
+func (p *Parser) parsePattern() ast.Pattern {
+	pos := p.cur.Pos
+	pattern := p.parseAlternative()
+	if pattern == nil || !p.peekTokenIs(token.PIPE) {
+		return pattern
+	}
+
+	or := &ast.OrPattern{Alternatives: []ast.Pattern{pattern}}
+	for p.peekTokenIs(token.PIPE) {
+		p.nextToken()
+		p.nextToken()
+		alt := p.parseAlternative()
+		if alt == nil {
+			return nil
+		}
+		or.Alternatives = append(or.Alternatives, alt)
+	}
+
+	want := names(or.Alternatives[0])
+	for _, alt := range or.Alternatives[1:] {
+		if got := names(alt); got != want {
+			msg := fmt.Sprintf("%s: alternatives of %s bind different names: [%s] and [%s]", pos, or, want, got)
+			p.errors = append(p.errors, msg)
+			break
+		}
+	}
+
+	return or
+}
+
+// names lists the names bound by pattern in a canonical order.
+func names(pattern ast.Pattern) string {
+	var list []string
+	for _, name := range ast.PatternNames(pattern) {
+		list = append(list, name.Value)
+	}
+	sort.Strings(list)
+
+	return strings.Join(list, ", ")
+}
+
+// parseAlternative parses a pattern without alternatives: a name, the
+// wildcard _, a literal, an enum variant, an array pattern or a hash
+// pattern.
+func (p *Parser) parseAlternative() ast.Pattern {
+	switch p.cur.Token {
+	case token.IDENT:
+		switch {
+		case p.cur.Literal == "_":
+			return &ast.WildcardPattern{Token: p.cur}
+		case p.peekTokenIs(token.LPAREN):
+			return p.parseVariantPattern()
+		case p.inArm && isUpper(p.cur.Literal):
+			return &ast.VariantPattern{Token: p.cur, Name: &ast.Identifier{Token: p.cur, Value: p.cur.Literal}}
+		}
+		return &ast.Identifier{Token: p.cur, Value: p.cur.Literal}
+	case token.LBRAC:
+		return p.parseArrayPattern()
+	case token.LCURL:
+		return p.parseHashPattern()
+	case token.INT, token.STRING, token.TRUE, token.FALSE:
+		if value := p.prefixFn[p.cur.Token](); value != nil {
+			return &ast.LiteralPattern{Value: value}
+		}
+		return nil
+	case token.MINUS:
+		minus := &ast.PrefixExpression{Token: p.cur, Operator: p.cur.Literal}
+		if !p.expectToken(token.INT) {
+			return nil
+		}
+		if minus.Right = p.parseIntegralLiteral(); minus.Right == nil {
+			return nil
+		}
+		return &ast.LiteralPattern{Value: minus}
+	}
+
+	msg := fmt.Sprintf("%s: expected a pattern, but got %s", p.cur.Pos, describe(p.cur))
+	p.errors = append(p.errors, msg)
+	return nil
+}
+
+// parsePatternElement parses a pattern followed by an optional default.
+func (p *Parser) parsePatternElement() *ast.PatternElement {
+	pattern := p.parsePattern()
+	if pattern == nil {
+		return nil
+	}
+
+	element := &ast.PatternElement{Pattern: pattern}
+	p.parseDefault(&element.Default)
+	return element
+}
+
+// parseDefault parses `= value` into *def if it comes next. Assignments in
+// a default value must be parenthesized.
+func (p *Parser) parseDefault(def *ast.Expression) {
+	if !p.peekTokenIs(token.ASSIGN) {
+		return
+	}
+
+	p.nextToken()
+	p.nextToken()
+	*def = p.parseExpression(token.ASSIGNMENT)
+}
+
+func (p *Parser) parseArrayPattern() ast.Pattern {
+	pattern := &ast.ArrayPattern{Token: p.cur}
+
+	for !p.peekTokenIs(token.RBRAC) {
+		p.nextToken()
+
+		if p.curTokenIs(token.ELLIPSIS) {
+			if !p.expectToken(token.IDENT) {
+				return nil
+			}
+			pattern.Rest = &ast.Identifier{Token: p.cur, Value: p.cur.Literal}
+
+			if p.peekTokenIs(token.COMMA) {
+				p.nextToken()
+			}
+			if !p.peekTokenIs(token.RBRAC) {
+				msg := fmt.Sprintf("%s: rest element ...%s must be last", pattern.Rest.Token.Pos, pattern.Rest.Value)
+				p.errors = append(p.errors, msg)
+				return nil
+			}
+			break
+		}
+
+		element := p.parsePatternElement()
+		if element == nil {
+			return nil
+		}
+		pattern.Elements = append(pattern.Elements, element)
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+
+	if !p.expectToken(token.RBRAC) {
+		return nil
+	}
+
+	return pattern
+}
+
+func (p *Parser) parseHashPattern() ast.Pattern {
+	pattern := &ast.HashPattern{Token: p.cur}
+
+	for !p.peekTokenIs(token.RCURL) {
+		if !p.expectToken(token.IDENT) {
+			return nil
+		}
+		entry := &ast.HashEntryPattern{Key: &ast.Identifier{Token: p.cur, Value: p.cur.Literal}}
+
+		if p.peekTokenIs(token.COLON) {
+			p.nextToken()
+			p.nextToken()
+			entry.Value = p.parsePatternElement()
+			if entry.Value == nil {
+				return nil
+			}
+		} else {
+			entry.Value = &ast.PatternElement{Pattern: entry.Key}
+			p.parseDefault(&entry.Value.Default)
+		}
+		pattern.Entries = append(pattern.Entries, entry)
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+
+	if !p.expectToken(token.RCURL) {
+		return nil
+	}
+
+	return pattern
+}
+
+// checkDuplicates reports the names that are bound more than once by one
+// pattern or parameter list. what says what the names are.
+func (p *Parser) checkDuplicates(names []*ast.Identifier, seen map[string]bool, what string) {
+	for _, name := range names {
+		if seen[name.Value] {
+			msg := fmt.Sprintf("%s: duplicate %s %s", name.Token.Pos, what, name.Value)
+			p.errors = append(p.errors, msg)
+		}
+		seen[name.Value] = true
+	}
+}
@@ -0,0 +1,181 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/0xedb/intlang/ast"
+	"github.com/0xedb/intlang/token"
+)
+
+// parseStructStatement parses `struct Name { field, ... }`, with fields
+// separated by commas or newlines.
+func (p *Parser) parseStructStatement() *ast.StructStatement {
+	stmt := &ast.StructStatement{Token: p.cur}
+
+	if !p.expectToken(token.IDENT) {
+		return nil
+	}
+	stmt.Name = &ast.Identifier{Token: p.cur, Value: p.cur.Literal}
+
+	if !p.expectToken(token.LCURL) {
+		return nil
+	}
+
+	ok := p.parseBraced("struct", "field", func() bool {
+		if !p.curTokenIs(token.IDENT) {
+			msg := fmt.Sprintf("%s: expected a field name, but got %s", p.cur.Pos, describe(p.cur))
+			p.errors = append(p.errors, msg)
+			return false
+		}
+		stmt.Fields = append(stmt.Fields, &ast.Identifier{Token: p.cur, Value: p.cur.Literal})
+		return true
+	})
+	if !ok {
+		return nil
+	}
+	p.checkDuplicates(stmt.Fields, map[string]bool{}, "field")
+
+	fields := make([]string, len(stmt.Fields))
+	for i, f := range stmt.Fields {
+		fields[i] = f.Value
+	}
+	p.structs[stmt.Name.Value] = fields
+
+	if !p.endStatement() {
+		return nil
+	}
+
+	return stmt
+}
+
+// parseStructLiteral parses `Name { field: value, ... }` starting at the
+// struct's name. If the struct was declared earlier in the same source, its
+// fields are checked here; otherwise they are checked when it is evaluated.
+func (p *Parser) parseStructLiteral() ast.Expression {
+	lit := &ast.StructLiteral{Token: p.cur, Name: &ast.Identifier{Token: p.cur, Value: p.cur.Literal}}
+	p.nextToken()
+
+	given := map[string]bool{}
+	ok := p.parseBraced(lit.Name.Value+" literal", "field", func() bool {
+		if !p.curTokenIs(token.IDENT) {
+			msg := fmt.Sprintf("%s: expected a field name, but got %s", p.cur.Pos, describe(p.cur))
+			p.errors = append(p.errors, msg)
+			return false
+		}
+		field := &ast.FieldValue{Name: &ast.Identifier{Token: p.cur, Value: p.cur.Literal}}
+		p.checkDuplicates([]*ast.Identifier{field.Name}, given, "field")
+
+		if !p.expectToken(token.COLON) {
+			return false
+		}
+		p.nextToken()
+		field.Value = p.parseExpression(token.LOWEST)
+
+		lit.Fields = append(lit.Fields, field)
+		return true
+	})
+	if !ok {
+		return nil
+	}
+
+	if fields, ok := p.structs[lit.Name.Value]; ok {
+		p.checkStructLiteral(lit, fields)
+	}
+
+	return lit
+}
+
+func (p *Parser) checkStructLiteral(lit *ast.StructLiteral, fields []string) {
+	declared := map[string]bool{}
+	for _, f := range fields {
+		declared[f] = true
+	}
+
+	given := map[string]bool{}
+	for _, f := range lit.Fields {
+		given[f.Name.Value] = true
+		if !declared[f.Name.Value] {
+			msg := fmt.Sprintf("%s: struct %s has no field %s", f.Name.Token.Pos, lit.Name.Value, f.Name.Value)
+			p.errors = append(p.errors, msg)
+		}
+	}
+
+	for _, f := range fields {
+		if !given[f] {
+			msg := fmt.Sprintf("%s: missing field %s in %s literal", lit.Token.Pos, f, lit.Name.Value)
+			p.errors = append(p.errors, msg)
+		}
+	}
+}
+
+func (p *Parser) parseMemberExpression(object ast.Expression) ast.Expression {
+	exp := &ast.MemberExpression{Token: p.cur, Object: object}
+	if !p.expectToken(token.IDENT) {
+		return nil
+	}
+	exp.Member = &ast.Identifier{Token: p.cur, Value: p.cur.Literal}
+
+	return exp
+}
+
+// parseOptionalExpression parses an optional member, `a?.b`, or an optional
+// call, `f?.(x)`.
+func (p *Parser) parseOptionalExpression(left ast.Expression) ast.Expression {
+	if p.peekTokenIs(token.LPAREN) {
+		p.nextToken()
+		call := &ast.CallExpression{Token: p.cur, Function: left, Optional: true}
+		p.parseCallArguments(call)
+		return call
+	}
+
+	exp := p.parseMemberExpression(left)
+	if exp == nil {
+		return nil
+	}
+	exp.(*ast.MemberExpression).Optional = true
+
+	return exp
+}
+
+// parseImplStatement parses `impl Type { fn name(params) { ... } ... }`,
+// with methods separated by newlines or semicolons.
+func (p *Parser) parseImplStatement() *ast.ImplStatement {
+	stmt := &ast.ImplStatement{Token: p.cur}
+
+	if !p.expectToken(token.IDENT) {
+		return nil
+	}
+	stmt.Type = &ast.Identifier{Token: p.cur, Value: p.cur.Literal}
+
+	if !p.expectToken(token.LCURL) {
+		return nil
+	}
+	seen := map[string]bool{}
+
+	ok := p.parseBraced("impl", "method", func() bool {
+		if !p.curTokenIs(token.FUNCTION) || !p.peekTokenIs(token.IDENT) {
+			msg := fmt.Sprintf("%s: expected a method declaration, but got %s", p.cur.Pos, describe(p.cur))
+			p.errors = append(p.errors, msg)
+			return false
+		}
+		fnToken := p.cur
+		p.nextToken()
+		name := &ast.Identifier{Token: p.cur, Value: p.cur.Literal}
+		p.checkDuplicates([]*ast.Identifier{name}, seen, "method")
+
+		method, ok := p.parseFunctionLiteral().(*ast.FunctionLiteral)
+		if !ok || method == nil {
+			return false
+		}
+		method.Token = fnToken
+		method.Name = name.Value
+
+		stmt.Methods = append(stmt.Methods, method)
+		return true
+	})
+	if !ok || !p.endStatement() {
+		return nil
+	}
+
+	return stmt
+}
@@ -0,0 +1,42 @@
+package parser
+
+import "github.com/0xedb/intlang/ast"
+
+// markTailCalls marks the calls in tail position in block, the body of a
+// function or a block in tail position in one: a call that is the value of
+// its last statement, including through if and match, or that its last
+// statement returns. Calls returned by ret elsewhere in the body are marked
+// as they are parsed.
+func markTailCalls(block *ast.BlockStatement) {
+	if block == nil || len(block.Statements) == 0 {
+		return
+	}
+
+	switch stmt := block.Statements[len(block.Statements)-1].(type) {
+	case *ast.ExpressionStatement:
+		markTail(stmt.Expression)
+	case *ast.ReturnStatement:
+		markTail(stmt.ReturnValue)
+	}
+}
+
+// markTail marks exp as a tail call if it is a call, or the calls in tail
+// position in its branches if it is an if or match expression.
+func markTail(exp ast.Expression) {
+	switch exp := exp.(type) {
+	case *ast.CallExpression:
+		exp.Tail = true
+	case *ast.IfExpression:
+		markTailCalls(exp.Consequence)
+		markTailCalls(exp.Alternative)
+	case *ast.MatchExpression:
+		for _, arm := range exp.Arms {
+			switch body := arm.Body.(type) {
+			case *ast.BlockStatement:
+				markTailCalls(body)
+			case ast.Expression:
+				markTail(body)
+			}
+		}
+	}
+}
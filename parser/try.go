@@ -0,0 +1,116 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/0xedb/intlang/ast"
+	"github.com/0xedb/intlang/token"
+)
+
+func (p *Parser) parseThrowStatement() *ast.ThrowStatement {
+	stmt := &ast.ThrowStatement{Token: p.cur}
+
+	if p.peekTokenIs(token.SEMICOLON) || p.peekTokenIs(token.RCURL) || p.peekTokenIs(token.EOF) {
+		msg := fmt.Sprintf("%s: throw needs a value to throw", p.cur.Pos)
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+	p.nextToken()
+	stmt.Value = p.parseExpression(token.LOWEST)
+
+	if !p.endStatement() {
+		return nil
+	}
+
+	return stmt
+}
+
+// parseTryExpression parses `try { } catch (e) { } finally { }`. As with
+// el, each clause must start on the line the previous block ends on.
+func (p *Parser) parseTryExpression() ast.Expression {
+	expression := &ast.TryExpression{Token: p.cur}
+
+	// A call returned from inside a try is not a tail call: the try has to
+	// catch its errors, and run finally after it.
+	tails := p.tails
+	p.tails = false
+	defer func() { p.tails = tails }()
+
+	if !p.expectToken(token.LCURL) {
+		return nil
+	}
+	expression.Block = p.parseBlockStatement()
+
+	if p.peekTokenIs(token.CATCH) {
+		p.nextToken()
+
+		if p.peekTokenIs(token.LPAREN) {
+			p.nextToken()
+			if !p.expectToken(token.IDENT) {
+				return nil
+			}
+			expression.Param = &ast.Identifier{Token: p.cur, Value: p.cur.Literal}
+			if !p.expectToken(token.RPAREN) {
+				return nil
+			}
+		}
+
+		if !p.expectToken(token.LCURL) {
+			return nil
+		}
+		expression.Catch = p.parseBlockStatement()
+	}
+
+	if p.peekTokenIs(token.FINALLY) {
+		p.nextToken()
+		if !p.expectToken(token.LCURL) {
+			return nil
+		}
+		expression.Finally = p.parseBlockStatement()
+	}
+
+	if expression.Catch == nil && expression.Finally == nil {
+		msg := fmt.Sprintf("%s: try at %s needs a catch or finally clause, but got %s",
+			p.peek.Pos, expression.Token.Pos, describe(p.peek))
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+
+	return expression
+}
+
+func (p *Parser) parseDeferStatement() *ast.DeferStatement {
+	stmt := &ast.DeferStatement{Token: p.cur}
+	p.nextToken()
+
+	start := p.cur.Pos
+	exp := p.parseExpression(token.LOWEST)
+	if exp == nil {
+		return nil
+	}
+
+	call, ok := exp.(*ast.CallExpression)
+	if !ok {
+		msg := fmt.Sprintf("%s: defer needs a function call, but got %s", start, exp)
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+	for _, arg := range call.Arguments {
+		if _, ok := arg.(*ast.Placeholder); ok {
+			msg := fmt.Sprintf("%s: cannot defer %s, which leaves out arguments", start, call)
+			p.errors = append(p.errors, msg)
+			return nil
+		}
+	}
+	stmt.Call = call
+
+	if !p.endStatement() {
+		return nil
+	}
+
+	return stmt
+}
+
+func (p *Parser) parsePropagateExpression(value ast.Expression) ast.Expression {
+	return &ast.PropagateExpression{Token: p.cur, Value: value}
+}
@@ -7,8 +7,10 @@ import (
 	"log"
 	"os/user"
 
+	"github.com/0xedb/intlang/checker"
 	"github.com/0xedb/intlang/evaluator"
 	"github.com/0xedb/intlang/lexer"
+	"github.com/0xedb/intlang/object"
 	"github.com/0xedb/intlang/parser"
 )
 
@@ -37,6 +39,9 @@ func StartREPL(in io.Reader, out io.Writer) {
 	fmt.Println("Hello, ", user.Name)
 	fmt.Println("Welcome to the intLANG programming language")
 
+	env := object.NewEnvironment()
+	check := checker.New()
+
 	for {
 		fmt.Print(PROMPT)
 		scanned := scanner.Scan()
@@ -49,10 +54,21 @@ func StartREPL(in io.Reader, out io.Writer) {
 		program := p.ParseProgram()
 
 		if len(p.Errors()) != 0 {
-			printParserErrors(out, p.Errors())
+			printErrors(out, "parser", p.Errors())
+			continue
+		}
+		if errs := check.Check(program); len(errs) != 0 {
+			printErrors(out, "checker", errs)
+			continue
+		}
+		for _, msg := range check.Warnings() {
+			io.WriteString(out, "warning: "+msg+"\n")
+		}
+		evaluated := evaluator.Eval(program, env)
+		if err, ok := evaluated.(*object.Error); ok {
+			io.WriteString(out, err.Trace())
 			continue
 		}
-		evaluated := evaluator.Eval(program)
 		if evaluated != nil {
 			io.WriteString(out, evaluated.Inspect())
 			io.WriteString(out, "\n")
@@ -60,10 +76,39 @@ func StartREPL(in io.Reader, out io.Writer) {
 	}
 }
 
-func printParserErrors(out io.Writer, errors []string) {
+// Run parses, checks and evaluates the whole program read from in, writing
+// any errors, and the trace of a runtime error, to out. The program is
+// lexed as it is read, so a read error is reported with the parser errors.
+// It reports whether the program ran to completion.
+func Run(in io.Reader, out io.Writer) bool {
+	p := parser.New(lexer.NewReader(in))
+	program := p.ParseProgram()
+
+	if len(p.Errors()) != 0 {
+		printErrors(out, "parser", p.Errors())
+		return false
+	}
+	check := checker.New()
+	if errs := check.Check(program); len(errs) != 0 {
+		printErrors(out, "checker", errs)
+		return false
+	}
+	for _, msg := range check.Warnings() {
+		io.WriteString(out, "warning: "+msg+"\n")
+	}
+
+	if err, ok := evaluator.Eval(program, object.NewEnvironment()).(*object.Error); ok {
+		io.WriteString(out, err.Trace())
+		return false
+	}
+
+	return true
+}
+
+func printErrors(out io.Writer, stage string, errors []string) {
 	io.WriteString(out, GREET)
 	io.WriteString(out, "Woops! We ran into some monkye business here!\n")
-	io.WriteString(out, "  parser errors:\n")
+	io.WriteString(out, "  "+stage+" errors:\n")
 
 	for _, msg := range errors {
 		io.WriteString(out, "\t"+msg+"\n")
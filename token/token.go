@@ -1,7 +1,9 @@
 package token
 
 import (
-	"regexp"
+	"fmt"
+	"unicode"
+	"unicode/utf8"
 )
 
 type (
@@ -10,20 +12,36 @@ type (
 	TokenObj struct {
 		Token   Token
 		Literal string
+		Pos     Position
+	}
+
+	// Position is the 1-based line and column at which a token starts.
+	Position struct {
+		Line, Column int
 	}
 
 	none struct{}
 )
 
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
 const (
 	_ int = iota
 	LOWEST
+	ASSIGNMENT  // x = y or x += y
+	PIPELINE    // x |> f()
+	COALESCE    // x ?? y
 	EQUALS      // ==
 	LESSGREATER // > or <
+	RANGE       // a..b
 	SUM         // +
 	PRODUCT     // *
 	PREFIX      // -X or !X
 	CALL        // myFunction(X)
+	INDEX       // array[index]
+	MEMBER      // point.x
 )
 
 const (
@@ -39,13 +57,31 @@ const (
 	MINUS = "-"
 	MULT  = "*"
 	DIV   = "/"
+	MOD   = "%"
 	NOT   = "!"
 
-	ASSIGN = "="
-	EQL    = "=="
-	NEQL   = "!="
-	LST    = "<"
-	GRT    = ">"
+	ASSIGN       = "="
+	PLUS_ASSIGN  = "+="
+	MINUS_ASSIGN = "-="
+	MULT_ASSIGN  = "*="
+	DIV_ASSIGN   = "/="
+	MOD_ASSIGN   = "%="
+
+	EQL      = "=="
+	NEQL     = "!="
+	LST      = "<"
+	GRT      = ">"
+	DOT      = "."
+	DOTDOT   = ".."
+	ELLIPSIS = "..."
+	ARROW    = "=>"
+	PIPE     = "|"
+	PIPE_GT  = "|>"
+	QUESTION = "?"
+
+	OPTIONAL_DOT   = "?."
+	OPTIONAL_LBRAC = "?["
+	NULLISH        = "??"
 
 	COMMA     = ","
 	COLON     = ":"
@@ -64,11 +100,32 @@ const (
 	IF       = "if"
 	EL       = "el"
 	RET      = "ret"
+	WHILE    = "while"
+	FOR      = "for"
+	IN       = "in"
+	BREAK    = "break"
+	CONTINUE = "continue"
+	MUT      = "mut"
+	MATCH    = "match"
+	ENUM     = "enum"
+	STRUCT   = "struct"
+	IMPL     = "impl"
+	THROW    = "throw"
+	TRY      = "try"
+	CATCH    = "catch"
+	FINALLY  = "finally"
+	DEFER    = "defer"
 )
 
 var keywords map[string]none
 var precedence map[string]int
 
+// spellings maps the keywords that have a second spelling, such as func for
+// fn, to their token.
+var spellings = map[string]Token{
+	"func": FUNCTION,
+}
+
 func init() {
 	keywords = map[string]none{
 		FUNCTION: none{},
@@ -78,21 +135,56 @@ func init() {
 		IF:       none{},
 		EL:       none{},
 		RET:      none{},
+		WHILE:    none{},
+		FOR:      none{},
+		IN:       none{},
+		BREAK:    none{},
+		CONTINUE: none{},
+		MUT:      none{},
+		MATCH:    none{},
+		ENUM:     none{},
+		STRUCT:   none{},
+		IMPL:     none{},
+		THROW:    none{},
+		TRY:      none{},
+		CATCH:    none{},
+		FINALLY:  none{},
+		DEFER:    none{},
+		"func":   none{},
 	}
 
 	precedence = map[string]int{
-		ASSIGN: EQUALS,
-		NEQL:   EQUALS,
-		LST:    LESSGREATER,
-		GRT:    LESSGREATER,
-		PLUS:   SUM,
-		MINUS:  SUM,
-		DIV:    PRODUCT,
-		MULT:   PRODUCT,
+		ASSIGN:         ASSIGNMENT,
+		PLUS_ASSIGN:    ASSIGNMENT,
+		MINUS_ASSIGN:   ASSIGNMENT,
+		MULT_ASSIGN:    ASSIGNMENT,
+		DIV_ASSIGN:     ASSIGNMENT,
+		MOD_ASSIGN:     ASSIGNMENT,
+		PIPE_GT:        PIPELINE,
+		NULLISH:        COALESCE,
+		EQL:            EQUALS,
+		NEQL:           EQUALS,
+		LST:            LESSGREATER,
+		GRT:            LESSGREATER,
+		DOTDOT:         RANGE,
+		PLUS:           SUM,
+		MINUS:          SUM,
+		DIV:            PRODUCT,
+		MULT:           PRODUCT,
+		MOD:            PRODUCT,
+		LPAREN:         CALL,
+		QUESTION:       CALL,
+		LBRAC:          INDEX,
+		DOT:            MEMBER,
+		OPTIONAL_LBRAC: INDEX,
+		OPTIONAL_DOT:   MEMBER,
 	}
 }
 
 func LookupIdentifier(id string) Token {
+	if tok, found := spellings[id]; found {
+		return tok
+	}
 	if _, found := keywords[id]; found {
 		return Token(id)
 	}
@@ -104,16 +196,50 @@ func (tok Token) IsKeyWord() bool {
 	return found
 }
 
-func IsLetter(tok byte) bool {
-	re := regexp.MustCompile(`[a-zA-Z_]`)
+const (
+	classLetter uint8 = 1 << iota
+	classDigit
+)
+
+// asciiClass classifies every ASCII character so that the lexer's hot path
+// needs a single table lookup per character.
+var asciiClass = func() (class [utf8.RuneSelf]uint8) {
+	for ch := 'a'; ch <= 'z'; ch++ {
+		class[ch] |= classLetter
+		class[ch-'a'+'A'] |= classLetter
+	}
+	for ch := '0'; ch <= '9'; ch++ {
+		class[ch] |= classDigit
+	}
+	class['_'] |= classLetter
 
-	return re.MatchString(string(tok))
+	return class
+}()
+
+// IsLetter reports whether tok may start an identifier: '_' or any Unicode
+// letter.
+func IsLetter(tok rune) bool {
+	if 0 <= tok && tok < utf8.RuneSelf {
+		return asciiClass[tok]&classLetter != 0
+	}
+
+	return unicode.IsLetter(tok)
 }
 
-func IsNumber(tok byte) bool {
-	re := regexp.MustCompile(`[0-9]`)
+// IsDigit reports whether tok may continue an identifier as a digit, which
+// includes non-ASCII decimal digits.
+func IsDigit(tok rune) bool {
+	if 0 <= tok && tok < utf8.RuneSelf {
+		return asciiClass[tok]&classDigit != 0
+	}
+
+	return unicode.IsDigit(tok)
+}
 
-	return re.MatchString(string(tok))
+// IsNumber reports whether tok may start a number literal. Only ASCII digits
+// do.
+func IsNumber(tok rune) bool {
+	return 0 <= tok && tok < utf8.RuneSelf && asciiClass[tok]&classDigit != 0
 }
 
 func LookupPrecedence(tok string) int {
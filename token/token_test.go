@@ -11,6 +11,9 @@ func TestKeyWords(t *testing.T) {
 		expect bool
 	}{
 		{"func", "func", true},
+		{"fn", "fn", true},
+		{"while", "while", true},
+		{"function", "function", false},
 		{"string", "string", false},
 		{"at", "@", true},
 	}
@@ -33,6 +36,8 @@ func TestLookupIdentifier(t *testing.T) {
 		expect Token
 	}{
 		{"At", "@", AT},
+		{"Fn", "fn", FUNCTION},
+		{"Func", "func", FUNCTION},
 		{"Ident", "test", IDENT},
 		{"RPAREN", ")", IDENT},
 	}
@@ -46,3 +51,32 @@ func TestLookupIdentifier(t *testing.T) {
 	}
 
 }
+
+func TestIsLetter(t *testing.T) {
+	tests := []struct {
+		name   string
+		ch     rune
+		letter bool
+		digit  bool
+	}{
+		{"ascii", 'a', true, false},
+		{"underscore", '_', true, false},
+		{"ascii digit", '7', false, true},
+		{"greek", 'λ', true, false},
+		{"han", '語', true, false},
+		{"arabic-indic digit", '٣', false, true},
+		{"symbol", '€', false, false},
+		{"negative", -1, false, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := IsLetter(test.ch); got != test.letter {
+				t.Fatalf("IsLetter Wanted: %t, Got: %t", test.letter, got)
+			}
+			if got := IsDigit(test.ch); got != test.digit {
+				t.Fatalf("IsDigit Wanted: %t, Got: %t", test.digit, got)
+			}
+		})
+	}
+}